@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// trackingParams are query parameters that only record where a click came from.
+// Parameters starting with "utm_" are dropped as well.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"yclid":   true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+	"ref_src": true,
+}
+
+// redirectors maps "host/path" of known redirect services to the query parameter carrying the real URL.
+// A key without a path covers every path on that host.
+var redirectors = map[string]string{
+	"www.google.com/url":       "q",
+	"google.com/url":           "q",
+	"l.facebook.com/l.php":     "u",
+	"lm.facebook.com/l.php":    "u",
+	"www.youtube.com/redirect": "q",
+	"t.umblr.com/redirect":     "z",
+	"out.reddit.com":           "url",
+}
+
+// canonicalizeURL reduces an article URL to a form that is the same however the article reached us:
+// known redirect wrappers are unwrapped, the scheme and host are lowercased, default ports, fragments
+// and tracking parameters are dropped and the remaining query parameters are sorted.
+// Anything that doesn't parse as an absolute URL is returned trimmed but otherwise unchanged.
+func canonicalizeURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return raw
+	}
+
+	// Redirectors can wrap each other, but a few levels is plenty
+	for i := 0; i < 3; i++ {
+		host := strings.ToLower(u.Host)
+		param, ok := redirectors[host+u.Path]
+		if !ok {
+			param, ok = redirectors[host]
+		}
+		if !ok {
+			break
+		}
+		target, err := url.Parse(u.Query().Get(param))
+		if err != nil || !target.IsAbs() || target.Host == "" {
+			break
+		}
+		u = target
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if (u.Scheme == "http" && u.Port() == "80") || (u.Scheme == "https" && u.Port() == "443") {
+		u.Host = u.Hostname()
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	query := u.Query()
+	for name := range query {
+		if trackingParams[strings.ToLower(name)] || strings.HasPrefix(strings.ToLower(name), "utm_") {
+			query.Del(name)
+		}
+	}
+	u.RawQuery = query.Encode() // Encode sorts by key
+
+	return u.String()
+}
+
+// errInvalidFeedURL is returned for feed URLs that we can't fetch.
+var errInvalidFeedURL = errors.New("feed URL must be an absolute http(s) URL")
+
+// normalizeFeedURL reduces a feed URL to the form feeds are stored and deduplicated by: the scheme and host
+// are lowercased and default ports, fragments and surrounding spaces are dropped. Unlike article URLs the
+// query is left alone, since feeds often need it. The migration that merged existing duplicates mirrors this.
+func normalizeFeedURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return "", errInvalidFeedURL
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", errInvalidFeedURL
+	}
+
+	u.Host = strings.ToLower(u.Host)
+	if (u.Scheme == "http" && u.Port() == "80") || (u.Scheme == "https" && u.Port() == "443") {
+		u.Host = u.Hostname()
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String(), nil
+}
+
+var (
+	linkTagPattern   = regexp.MustCompile(`(?i)<link\s[^>]*>`)
+	attributePattern = regexp.MustCompile(`(?i)([a-z-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// canonicalLink returns the href of the first <link rel="canonical"> in an article's full text,
+// or "" if there is none. Only absolute http(s) links count, relative ones would need the page's URL.
+func canonicalLink(content string) string {
+	for _, tag := range linkTagPattern.FindAllString(content, -1) {
+		var rel, href string
+		for _, attr := range attributePattern.FindAllStringSubmatch(tag, -1) {
+			value := html.UnescapeString(attr[2] + attr[3] + attr[4])
+			switch strings.ToLower(attr[1]) {
+			case "rel":
+				rel = value
+			case "href":
+				href = strings.TrimSpace(value)
+			}
+		}
+		isCanonical := false
+		for _, r := range strings.Fields(rel) {
+			if strings.EqualFold(r, "canonical") {
+				isCanonical = true
+			}
+		}
+		if !isCanonical {
+			continue
+		}
+		u, err := url.Parse(href)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			continue
+		}
+		return href
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package main
+
+import "testing"
+
+// TestCanonicalizeURL checks that different spellings of the same article URL collapse to one
+func TestCanonicalizeURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://Example.COM/post?id=1", "https://example.com/post?id=1"},
+		{"https://example.com/post?utm_source=rss&utm_medium=feed&id=1", "https://example.com/post?id=1"},
+		{"https://example.com/post?fbclid=abc", "https://example.com/post"},
+		{"https://example.com/post?b=2&a=1", "https://example.com/post?a=1&b=2"},
+		{"https://example.com:443/post#comments", "https://example.com/post"},
+		{"http://example.com:80", "http://example.com/"},
+		{"http://example.com:8080/post", "http://example.com:8080/post"},
+		{"https://www.google.com/url?q=https%3A%2F%2Fexample.com%2Fpost%3Futm_campaign%3Dx&sa=D", "https://example.com/post"},
+		{"https://l.facebook.com/l.php?u=https%3A%2F%2Fexample.com%2Fpost&h=AT0", "https://example.com/post"},
+		{"https://out.reddit.com/t3_1abcde?url=https%3A%2F%2Fexample.com%2Fpost&token=x", "https://example.com/post"},
+		{"  https://example.com/post  ", "https://example.com/post"},
+		{"not a url", "not a url"},
+		{"/relative/path", "/relative/path"},
+	}
+
+	for _, tt := range tests {
+		got := canonicalizeURL(tt.in)
+		if got != tt.want {
+			t.Errorf("canonicalizeURL(%q) = %q, expected %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeFeedURL(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"https://Example.COM/feed.xml", "https://example.com/feed.xml", false},
+		{" HTTP://example.com:80 ", "http://example.com/", false},
+		{"https://example.com:443/rss#top", "https://example.com/rss", false},
+		{"https://example.com/rss?utm_source=x&b=2&a=1", "https://example.com/rss?utm_source=x&b=2&a=1", false},
+		{"https://example.com/Feed", "https://example.com/Feed", false},
+		{"ftp://example.com/feed", "", true},
+		{"example.com/feed", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeFeedURL(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeFeedURL(%q) = %q, %v, expected %q (error: %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCanonicalLink(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`<p>Hi</p><link rel="canonical" href="https://example.com/a?b=1&amp;c=2">`, "https://example.com/a?b=1&c=2"},
+		{`<LINK HREF='https://example.com/a' REL='Canonical' />`, "https://example.com/a"},
+		{`<link rel=canonical href=https://example.com/a>`, "https://example.com/a"},
+		{`<link rel="stylesheet" href="https://example.com/a.css"><link rel="canonical" href="https://example.com/b">`, "https://example.com/b"},
+		{`<link rel="canonical" href="/relative">`, ""},
+		{`<link rel="alternate" href="https://example.com/a">`, ""},
+		{`<p>rel="canonical" href="https://example.com/a"</p>`, ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		got := canonicalLink(tt.in)
+		if got != tt.want {
+			t.Errorf("canonicalLink(%q) = %q, expected %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// envDuration reads a duration such as "15m" or "2h" from the environment, falling back to def when it is unset.
+func envDuration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Fatalf("%s must be a valid non-negative duration, got %q", key, value)
+	}
+	return d
+}
+
+// envInt reads a non-negative integer from the environment, falling back to def when it is unset.
+func envInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Fatalf("%s must be a valid non-negative integer, got %q", key, value)
+	}
+	return n
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	htmltemplate "html/template"
+	"log"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+	_ "time/tzdata" // Digest timezones must resolve even where the system has no zoneinfo
+
+	"github.com/PuneethM06/rssagg/internal/database"
+)
+
+// digestMaxPosts caps how many posts one digest lists.
+const digestMaxPosts = 100
+
+// digestRetryDelay is how long a digest waits after failing to send.
+const digestRetryDelay = 15 * time.Minute
+
+// nextDigestAt returns the first time after now that a digest is due: minuteOfDay local time in loc,
+// every day or, for weekly digests, on weekday. The wall clock time holds across DST changes.
+func nextDigestAt(now time.Time, frequency string, minuteOfDay int, weekday time.Weekday, loc *time.Location) time.Time {
+	local := now.In(loc)
+	days := 0
+	step := 1
+	if frequency == "weekly" {
+		days = (int(weekday) - int(local.Weekday()) + 7) % 7
+		step = 7
+	}
+
+	at := func(days int) time.Time {
+		return time.Date(local.Year(), local.Month(), local.Day()+days, minuteOfDay/60, minuteOfDay%60, 0, 0, loc)
+	}
+	next := at(days)
+	if !next.After(now) {
+		next = at(days + step)
+	}
+	return next.UTC()
+}
+
+// digestPeriod is how far back the first digest looks, before there is a last one to start from.
+func digestPeriod(frequency string) time.Duration {
+	if frequency == "weekly" {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// scheduleDigest works out the next send time for a user's settings.
+func scheduleDigest(now time.Time, settings database.DigestSetting) (time.Time, error) {
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return nextDigestAt(now, settings.Frequency, int(settings.MinuteOfDay), time.Weekday(settings.Weekday.Int32), loc), nil
+}
+
+// digestFeed is one feed's section of a digest.
+type digestFeed struct {
+	Name  string
+	Posts []database.GetDigestPostsRow
+}
+
+// digestData is what the digest templates render.
+type digestData struct {
+	UserName  string
+	Frequency string
+	Count     int
+	Feeds     []digestFeed
+	More      bool // There were more unread posts than the digest lists
+}
+
+var digestTextTemplate = texttemplate.Must(texttemplate.New("digest").Parse(`Hi {{.UserName}},
+
+Here is your {{.Frequency}} digest: {{.Count}} new unread post{{if ne .Count 1}}s{{end}}.
+{{range .Feeds}}
+== {{.Name}} ==
+{{range .Posts}}
+- {{.Title}}
+  {{.Url}}
+{{end}}{{end}}{{if .More}}
+There are more unread posts waiting for you.
+{{end}}`))
+
+var digestHTMLTemplate = htmltemplate.Must(htmltemplate.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; max-width: 640px;">
+<p>Hi {{.UserName}},</p>
+<p>Here is your {{.Frequency}} digest: {{.Count}} new unread post{{if ne .Count 1}}s{{end}}.</p>
+{{range .Feeds}}
+<h2 style="font-size: 1.1em;">{{.Name}}</h2>
+<ul>
+{{range .Posts}}<li><a href="{{.Url}}">{{.Title}}</a></li>
+{{end}}</ul>
+{{end}}{{if .More}}<p>There are more unread posts waiting for you.</p>
+{{end}}</body>
+</html>
+`))
+
+// renderDigest builds the digest email for posts, which come sorted by feed name.
+func renderDigest(userName, email, frequency string, posts []database.GetDigestPostsRow, more bool) (mailMessage, error) {
+	data := digestData{
+		UserName:  userName,
+		Frequency: frequency,
+		Count:     len(posts),
+		More:      more,
+	}
+	for _, post := range posts {
+		if len(data.Feeds) == 0 || data.Feeds[len(data.Feeds)-1].Name != post.FeedName {
+			data.Feeds = append(data.Feeds, digestFeed{Name: post.FeedName})
+		}
+		feed := &data.Feeds[len(data.Feeds)-1]
+		feed.Posts = append(feed.Posts, post)
+	}
+
+	text := &strings.Builder{}
+	err := digestTextTemplate.Execute(text, data)
+	if err != nil {
+		return mailMessage{}, err
+	}
+	html := &bytes.Buffer{}
+	err = digestHTMLTemplate.Execute(html, data)
+	if err != nil {
+		return mailMessage{}, err
+	}
+
+	subject := "Your " + frequency + " digest: 1 new post"
+	if len(posts) != 1 {
+		subject = "Your " + frequency + " digest: " + strconv.Itoa(len(posts)) + " new posts"
+	}
+	return mailMessage{
+		To:      email,
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// digestConfirmResendInterval is how often a confirmation email may go to the same address,
+// so the digest settings can't be used to flood someone else's inbox.
+const digestConfirmResendInterval = 10 * time.Minute
+
+var digestConfirmTextTemplate = texttemplate.Must(texttemplate.New("confirm").Parse(`Hi {{.UserName}},
+
+Someone asked for email digests of their RSS feeds to be sent to this address.
+If that was you, confirm it by opening this link:
+
+{{.Link}}
+
+If it wasn't, ignore this email and nothing will be sent.
+`))
+
+var digestConfirmHTMLTemplate = htmltemplate.Must(htmltemplate.New("confirm").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; max-width: 640px;">
+<p>Hi {{.UserName}},</p>
+<p>Someone asked for email digests of their RSS feeds to be sent to this address.
+If that was you, <a href="{{.Link}}">confirm it</a>.</p>
+<p>If it wasn't, ignore this email and nothing will be sent.</p>
+</body>
+</html>
+`))
+
+// renderDigestConfirmation builds the email asking the owner of an address to confirm it, through link.
+func renderDigestConfirmation(userName, email, link string) (mailMessage, error) {
+	data := struct{ UserName, Link string }{userName, link}
+
+	text := &strings.Builder{}
+	err := digestConfirmTextTemplate.Execute(text, data)
+	if err != nil {
+		return mailMessage{}, err
+	}
+	html := &bytes.Buffer{}
+	err = digestConfirmHTMLTemplate.Execute(html, data)
+	if err != nil {
+		return mailMessage{}, err
+	}
+
+	return mailMessage{
+		To:      email,
+		Subject: "Confirm your email digest",
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// digestScheduler sends the digests that are due, checking every PollInterval.
+// Due digests are leased in the database, so several instances can run it. Digests to unconfirmed addresses are never due.
+type digestScheduler struct {
+	DB           *database.Queries
+	Mailer       *mailer
+	PollInterval time.Duration
+	BatchSize    int
+}
+
+// start sends due digests until ctx is cancelled.
+func (s *digestScheduler) start(ctx context.Context) {
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.sendDue(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sendDue sends every digest that is due now.
+func (s *digestScheduler) sendDue(ctx context.Context) {
+	for {
+		now := time.Now().UTC()
+		// Claiming pushes next_send_at out, so a crashed instance's digests go out later instead of twice
+		claimed, err := s.DB.ClaimDueDigests(ctx, database.ClaimDueDigestsParams{
+			LeaseUntil: now.Add(digestRetryDelay),
+			Now:        now,
+			BatchSize:  int32(s.BatchSize),
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Println("Error claiming due digests:", err)
+			}
+			return
+		}
+
+		for _, settings := range claimed {
+			// Digests that were claimed but not started go out once their claim runs out
+			if ctx.Err() != nil {
+				return
+			}
+			// A digest that has started is allowed to finish on shutdown, but never for longer than smtpTimeout
+			digestCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), smtpTimeout)
+			s.sendDigest(digestCtx, settings, now)
+			cancel()
+		}
+		if len(claimed) < s.BatchSize {
+			return
+		}
+	}
+}
+
+// sendDigest emails one user their unread posts since the last digest and schedules the next one.
+// A digest with nothing in it is skipped, but still counts as sent.
+func (s *digestScheduler) sendDigest(ctx context.Context, settings database.DigestSetting, now time.Time) {
+	next, err := scheduleDigest(now, settings)
+	if err != nil {
+		// The timezone was checked when the settings were saved, so this only happens if tzdata changed
+		log.Printf("Error scheduling digest for user %s: %v", settings.UserID, err)
+		return
+	}
+
+	since := now.Add(-digestPeriod(settings.Frequency))
+	if settings.LastSentAt.Valid {
+		since = settings.LastSentAt.Time
+	}
+
+	err = s.deliver(ctx, settings, since, now)
+	if err != nil {
+		log.Printf("Error sending digest to user %s: %v", settings.UserID, err)
+		// The claim already moved next_send_at out by digestRetryDelay, so it is retried then
+		return
+	}
+
+	err = s.DB.MarkDigestSent(ctx, database.MarkDigestSentParams{
+		UserID:     settings.UserID,
+		LastSentAt: sql.NullTime{Time: now, Valid: true},
+		NextSendAt: next,
+	})
+	if err != nil {
+		log.Println("Error marking digest sent:", err)
+	}
+}
+
+func (s *digestScheduler) deliver(ctx context.Context, settings database.DigestSetting, since, now time.Time) error {
+	user, err := s.DB.GetUserByID(ctx, settings.UserID)
+	if err != nil {
+		return err
+	}
+	posts, err := s.DB.GetDigestPosts(ctx, database.GetDigestPostsParams{
+		UserID:    settings.UserID,
+		Since:     since,
+		FolderIds: settings.FolderIds,
+		PageSize:  digestMaxPosts + 1,
+	})
+	if err != nil {
+		return err
+	}
+	if len(posts) == 0 {
+		return nil
+	}
+
+	more := len(posts) > digestMaxPosts
+	if more {
+		posts = posts[:digestMaxPosts]
+	}
+	msg, err := renderDigest(user.Name, settings.Email, settings.Frequency, posts, more)
+	if err != nil {
+		return err
+	}
+	return s.Mailer.send(ctx, msg, now)
+}
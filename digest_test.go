@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+)
+
+func TestNextDigestAt(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		now         time.Time
+		frequency   string
+		minuteOfDay int
+		weekday     time.Weekday
+		want        time.Time
+	}{
+		{
+			name:        "later today",
+			now:         time.Date(2024, 3, 5, 6, 0, 0, 0, time.UTC), // 01:00 in New York
+			frequency:   "daily",
+			minuteOfDay: 8 * 60,
+			want:        time.Date(2024, 3, 5, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "already sent today",
+			now:         time.Date(2024, 3, 5, 13, 0, 0, 0, time.UTC),
+			frequency:   "daily",
+			minuteOfDay: 8 * 60,
+			want:        time.Date(2024, 3, 6, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "keeps the wall clock time over the DST change",
+			now:         time.Date(2024, 3, 9, 14, 0, 0, 0, time.UTC), // Saturday 09:00 EST
+			frequency:   "daily",
+			minuteOfDay: 8 * 60,
+			want:        time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC), // Sunday 08:00 EDT
+		},
+		{
+			name:        "weekly later this week",
+			now:         time.Date(2024, 3, 5, 13, 0, 0, 0, time.UTC), // Tuesday
+			frequency:   "weekly",
+			minuteOfDay: 17*60 + 30,
+			weekday:     time.Friday,
+			want:        time.Date(2024, 3, 8, 22, 30, 0, 0, time.UTC),
+		},
+		{
+			name:        "weekly on the day but past the time",
+			now:         time.Date(2024, 3, 8, 23, 0, 0, 0, time.UTC), // Friday 18:00 EST
+			frequency:   "weekly",
+			minuteOfDay: 17*60 + 30,
+			weekday:     time.Friday,
+			want:        time.Date(2024, 3, 15, 21, 30, 0, 0, time.UTC), // EDT by then
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextDigestAt(tt.now, tt.frequency, tt.minuteOfDay, tt.weekday, newYork)
+			if !got.Equal(tt.want) {
+				t.Errorf("nextDigestAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeSMTPServer accepts one message and hands its sender, recipients and data to the returned channel.
+func fakeSMTPServer(t *testing.T) (string, <-chan smtpCapture) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	captured := make(chan smtpCapture, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+		got := smtpCapture{}
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL FROM:"):
+				got.From = strings.TrimSpace(line[len("MAIL FROM:"):])
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				got.To = append(got.To, strings.TrimSpace(line[len("RCPT TO:"):]))
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				data := &strings.Builder{}
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(strings.TrimPrefix(line, "."))
+				}
+				got.Data = data.String()
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 Bye")
+				captured <- got
+				return
+			default:
+				reply("502 Not implemented")
+			}
+		}
+	}()
+
+	return ln.Addr().String(), captured
+}
+
+type smtpCapture struct {
+	From string
+	To   []string
+	Data string
+}
+
+func TestDigestEmailIsSentOverSMTP(t *testing.T) {
+	addr, captured := fakeSMTPServer(t)
+	m := &mailer{Addr: addr, From: "digest@rss.example.com"}
+
+	posts := []database.GetDigestPostsRow{
+		{FeedName: "Go Blog", Title: "Generics & you", Url: "https://go.dev/blog/generics"},
+		{FeedName: "Go Blog", Title: "Range over func", Url: "https://go.dev/blog/range"},
+		{FeedName: "Postgres News", Title: "<script>alert(1)</script> 17 released", Url: "https://postgresql.org/17"},
+	}
+	msg, err := renderDigest("Ada", "ada@example.com", "daily", posts, false)
+	if err != nil {
+		t.Fatalf("renderDigest() error = %v", err)
+	}
+	err = m.send(context.Background(), msg, time.Date(2024, 3, 5, 13, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+
+	var got smtpCapture
+	select {
+	case got = <-captured:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the SMTP server never got the message")
+	}
+	if got.From != "<digest@rss.example.com>" || len(got.To) != 1 || got.To[0] != "<ada@example.com>" {
+		t.Errorf("envelope from %q to %q", got.From, got.To)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(got.Data))
+	if err != nil {
+		t.Fatalf("message does not parse: %v", err)
+	}
+	if subject := parsed.Header.Get("Subject"); subject != "Your daily digest: 3 new posts" {
+		t.Errorf("Subject = %q", subject)
+	}
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q", parsed.Header.Get("Content-Type"))
+	}
+
+	bodies := map[string]string{}
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	for {
+		part, err := parts.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies[strings.Split(part.Header.Get("Content-Type"), ";")[0]] = string(body)
+	}
+
+	text := bodies["text/plain"]
+	for _, want := range []string{"Hi Ada,", "3 new unread posts", "== Go Blog ==", "- Range over func", "https://postgresql.org/17"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text part is missing %q:\n%s", want, text)
+		}
+	}
+	html := bodies["text/html"]
+	if !strings.Contains(html, `<a href="https://go.dev/blog/generics">Generics &amp; you</a>`) {
+		t.Errorf("html part is missing the escaped link:\n%s", html)
+	}
+	if strings.Contains(html, "<script>") {
+		t.Errorf("html part does not escape post titles:\n%s", html)
+	}
+}
+
+// TestSendGivesUpWithItsContext checks that a server which never answers doesn't hold up the sender
+func TestSendGivesUpWithItsContext(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(io.Discard, conn) // Never sends the greeting
+	}()
+
+	m := &mailer{Addr: ln.Addr().String(), From: "digest@rss.example.com"}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = m.send(ctx, mailMessage{To: "ada@example.com", Subject: "Hi"}, time.Now())
+	if err == nil {
+		t.Fatal("send() succeeded without an answer from the server")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("send() took %s to give up", elapsed)
+	}
+}
+
+func TestRenderDigestConfirmation(t *testing.T) {
+	link := "https://rss.example.com/v1/digest/confirm?token=abc123"
+	msg, err := renderDigestConfirmation("<Ada>", "ada@example.com", link)
+	if err != nil {
+		t.Fatalf("renderDigestConfirmation() error = %v", err)
+	}
+	if msg.To != "ada@example.com" {
+		t.Errorf("To = %q, want ada@example.com", msg.To)
+	}
+	if !strings.Contains(msg.Text, link) || !strings.Contains(msg.HTML, `href="`+link+`"`) {
+		t.Errorf("the link is missing:\n%s\n%s", msg.Text, msg.HTML)
+	}
+	if strings.Contains(msg.HTML, "<Ada>") {
+		t.Error("user name is not escaped in the HTML part")
+	}
+}
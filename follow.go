@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+	"github.com/google/uuid"
+)
+
+// followResult describes what followFeedURL found and what it had to create.
+type followResult struct {
+	Feed          database.Feed
+	FeedFollow    database.FeedFollow
+	FeedCreated   bool // Nobody had added this feed before
+	FollowCreated bool // The user didn't follow it yet
+}
+
+// followFeedURL makes sure a feed exists for rawURL and that user follows it, in one transaction.
+// Feeds are unique by normalized URL, so adding a feed someone else already added returns theirs.
+// name, siteURL and folderID are only used for whatever has to be created.
+func (apiCfg *apiConfig) followFeedURL(ctx context.Context, user database.User, rawURL, name, siteURL string, folderID uuid.NullUUID) (followResult, error) {
+	result := followResult{}
+
+	feedURL, err := normalizeFeedURL(rawURL)
+	if err != nil {
+		return result, err
+	}
+	if name == "" {
+		name = feedURL
+	}
+
+	tx, err := apiCfg.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := apiCfg.DB.WithTx(tx)
+
+	// The insert does nothing if the URL is taken, even by a feed another request is adding right now
+	result.Feed, err = qtx.CreateFeed(ctx, database.CreateFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Name:      name,
+		Url:       feedURL,
+		UserID:    uuid.NullUUID{UUID: user.ID, Valid: true},
+		SiteUrl:   sql.NullString{String: siteURL, Valid: siteURL != ""},
+	})
+	result.FeedCreated = err == nil
+	if errors.Is(err, sql.ErrNoRows) {
+		result.Feed, err = qtx.GetFeedByURL(ctx, feedURL)
+	}
+	if err != nil {
+		return result, fmt.Errorf("getting or creating feed: %w", err)
+	}
+
+	result.FeedFollow, err = qtx.CreateFeedFollowIfMissing(ctx, database.CreateFeedFollowIfMissingParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		FeedID:    result.Feed.ID,
+		FolderID:  folderID,
+	})
+	result.FollowCreated = err == nil
+	if errors.Is(err, sql.ErrNoRows) {
+		// Existing follows keep whatever folder they are in
+		result.FeedFollow, err = qtx.GetFeedFollowForFeed(ctx, database.GetFeedFollowForFeedParams{
+			UserID: user.ID,
+			FeedID: result.Feed.ID,
+		})
+	}
+	if err != nil {
+		return result, fmt.Errorf("following feed: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return result, fmt.Errorf("committing follow: %w", err)
+	}
+	return result, nil
+}
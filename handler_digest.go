@@ -0,0 +1,209 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+	"github.com/google/uuid"
+)
+
+// weekdays maps the weekday names the digest API takes to time.Weekday.
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// handlerGetDigestSettings returns the caller's digest settings, 404 if they have no digest.
+func (apiCfg *apiConfig) handlerGetDigestSettings(w http.ResponseWriter, r *http.Request, user database.User) {
+	settings, err := apiCfg.DB.GetDigestSettings(r.Context(), user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "No digest set up")
+		return
+	}
+	if err != nil {
+		log.Printf("Error getting digest settings: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to get digest settings")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseDigestSettingToDigestSettings(settings))
+}
+
+// handlerPutDigestSettings sets up or changes the caller's email digest.
+func (apiCfg *apiConfig) handlerPutDigestSettings(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Email     string      `json:"email"`
+		Frequency string      `json:"frequency"`   // daily or weekly
+		TimeOfDay string      `json:"time_of_day"` // HH:MM local time
+		Weekday   string      `json:"weekday"`     // Weekly digests only, monday to sunday
+		Timezone  string      `json:"timezone"`    // IANA name, UTC if empty
+		FolderIDs []uuid.UUID `json:"folder_ids"`  // Empty for every followed feed
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if !validEmail(params.Email) {
+		respondWithError(w, http.StatusBadRequest, "email must be a valid email address")
+		return
+	}
+	if params.Frequency != "daily" && params.Frequency != "weekly" {
+		respondWithError(w, http.StatusBadRequest, "frequency must be daily or weekly")
+		return
+	}
+	timeOfDay, err := time.Parse("15:04", params.TimeOfDay)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "time_of_day must look like 08:30")
+		return
+	}
+	weekday := sql.NullInt32{}
+	if params.Frequency == "weekly" {
+		day, ok := weekdays[strings.ToLower(params.Weekday)]
+		if !ok {
+			respondWithError(w, http.StatusBadRequest, "weekday must be a day of the week for weekly digests")
+			return
+		}
+		weekday = sql.NullInt32{Int32: int32(day), Valid: true}
+	}
+	if params.Timezone == "" {
+		params.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(params.Timezone); err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Unknown timezone %q", params.Timezone))
+		return
+	}
+
+	// Folders must be the caller's own
+	folderIDs := []uuid.UUID{}
+	for _, folderID := range params.FolderIDs {
+		_, err := apiCfg.DB.GetFolderForUser(r.Context(), database.GetFolderForUserParams{
+			ID:     folderID,
+			UserID: user.ID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusBadRequest, "Unknown folder "+folderID.String())
+			return
+		}
+		if err != nil {
+			log.Printf("Error getting folder: %v", err)
+			respondWithError(w, http.StatusInternalServerError, "Unable to set digest settings")
+			return
+		}
+		folderIDs = append(folderIDs, folderID)
+	}
+
+	settings := database.UpsertDigestSettingsParams{
+		UserID:      user.ID,
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+		Email:       params.Email,
+		Frequency:   params.Frequency,
+		MinuteOfDay: int32(timeOfDay.Hour()*60 + timeOfDay.Minute()),
+		Weekday:     weekday,
+		Timezone:    params.Timezone,
+		FolderIds:   folderIDs,
+	}
+	settings.NextSendAt, err = scheduleDigest(time.Now().UTC(), database.DigestSetting{
+		Frequency:   settings.Frequency,
+		MinuteOfDay: settings.MinuteOfDay,
+		Weekday:     settings.Weekday,
+		Timezone:    settings.Timezone,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Changing the address needs a new confirmation, the other settings don't
+	saved, err := apiCfg.DB.UpsertDigestSettings(r.Context(), settings)
+	if err != nil {
+		log.Printf("Error setting digest settings: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to set digest settings")
+		return
+	}
+
+	// Every PUT resends the same link while the address is unconfirmed, but not too often
+	if !saved.EmailConfirmedAt.Valid && apiCfg.Mailer != nil {
+		allowed, _ := allowAll(time.Now(), rateLimit{apiCfg.DigestConfirmLimiter, strings.ToLower(saved.Email)})
+		if allowed {
+			err = apiCfg.sendDigestConfirmation(r, user, saved)
+			if err != nil {
+				log.Printf("Error sending digest confirmation: %v", err)
+				respondWithError(w, http.StatusBadGateway, "Unable to send the confirmation email")
+				return
+			}
+		}
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseDigestSettingToDigestSettings(saved))
+}
+
+// sendDigestConfirmation mails the link that confirms a digest's address.
+func (apiCfg *apiConfig) sendDigestConfirmation(r *http.Request, user database.User, settings database.DigestSetting) error {
+	link := absoluteURL(r, "/v1/digest/confirm?token="+url.QueryEscape(settings.ConfirmToken.String))
+	msg, err := renderDigestConfirmation(user.Name, settings.Email, link)
+	if err != nil {
+		return err
+	}
+	return apiCfg.Mailer.send(r.Context(), msg, time.Now().UTC())
+}
+
+// handlerConfirmDigestEmail serves the link from the confirmation email. The token in it stands in for
+// the API key, since the link is opened from a mail client. Digests are only sent once this was opened.
+func (apiCfg *apiConfig) handlerConfirmDigestEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respondWithError(w, http.StatusNotFound, "Unknown or used confirmation link")
+		return
+	}
+
+	settings, err := apiCfg.DB.ConfirmDigestEmail(r.Context(), database.ConfirmDigestEmailParams{
+		ConfirmToken:     sql.NullString{String: token, Valid: true},
+		EmailConfirmedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Unknown or used confirmation link")
+		return
+	}
+	if err != nil {
+		log.Printf("Error confirming digest email: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to confirm email")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, map[string]string{"message": "Confirmed, digests will be sent to " + settings.Email})
+}
+
+// handlerDeleteDigestSettings turns the caller's digest off.
+func (apiCfg *apiConfig) handlerDeleteDigestSettings(w http.ResponseWriter, r *http.Request, user database.User) {
+	deleted, err := apiCfg.DB.DeleteDigestSettings(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("Error deleting digest settings: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to delete digest settings")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "No digest set up")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, map[string]string{"message": "Digest turned off"})
+}
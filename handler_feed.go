@@ -1,16 +1,23 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/PuneethM06/rssagg/internal/database"
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
 // handlerCreateFeed handles creating a new feed.
+// Adding a URL that is already known returns the existing feed, and either way the caller ends up following it.
 func (apiCfg *apiConfig) handlerCreateFeed(w http.ResponseWriter, r *http.Request, user database.User) {
 	// Define expected JSON request structure
 	type parameters struct {
@@ -28,24 +35,32 @@ func (apiCfg *apiConfig) handlerCreateFeed(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Create a new feed entry in the database
-	feed, err := apiCfg.DB.CreateFeed(r.Context(), database.CreateFeedParams{
-		ID:        uuid.New(),                                // Generate unique feed ID
-		CreatedAt: time.Now().UTC(),                          // Set creation timestamp
-		UpdatedAt: time.Now().UTC(),                          // Set update timestamp
-		Name:      params.Name,                               // Store feed name
-		Url:       params.URL,                                // Store feed URL
-		UserID:    uuid.NullUUID{UUID: user.ID, Valid: true}, // Store user ID (nullable)
-	})
-
+	// Create the feed unless we have it already, and follow it for the creator
+	result, err := apiCfg.followFeedURL(r.Context(), user, params.URL, strings.TrimSpace(params.Name), "", uuid.NullUUID{})
+	if errors.Is(err, errInvalidFeedURL) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		log.Printf("Error creating feed: %v", err) // Log error
 		respondWithError(w, http.StatusInternalServerError, "Unable to create feed")
 		return
 	}
 
-	// Send the created feed as a response
-	respondwithJSON(w, http.StatusOK, feed)
+	// The body is still the feed, with the caller's follow alongside; 201 tells a new feed from a known one
+	type createFeedResponse struct {
+		Feed
+		FeedFollow FeedFollows `json:"feed_follow"`
+	}
+
+	status := http.StatusOK
+	if result.FeedCreated {
+		status = http.StatusCreated
+	}
+	respondwithJSON(w, status, createFeedResponse{
+		Feed:       databaseFeedToFeed(result.Feed),
+		FeedFollow: databaseFeedFollowToFeedFollow(result.FeedFollow),
+	})
 }
 
 // handlerGetFeeds retrieves all feeds from the database.
@@ -61,3 +76,236 @@ func (apiCfg *apiConfig) handlerGetFeeds(w http.ResponseWriter, r *http.Request)
 	// Send the retrieved feeds as a JSON response
 	respondwithJSON(w, http.StatusOK, databaseFeedsToFeeds(feed))
 }
+
+// handlerRefreshFeed fetches a feed right away instead of waiting for its turn in the queue.
+func (apiCfg *apiConfig) handlerRefreshFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	feed, ok := apiCfg.feedFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	// Throttle both the caller and the feed, so the source isn't hammered by many users at once
+	ok, wait := allowAll(time.Now(),
+		rateLimit{apiCfg.UserRefreshLimiter, user.ID.String()},
+		rateLimit{apiCfg.FeedRefreshLimiter, feed.ID.String()},
+	)
+	if !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		respondWithError(w, http.StatusTooManyRequests, "Refreshed too recently, try again later")
+		return
+	}
+
+	type refreshResponse struct {
+		FeedID     uuid.UUID `json:"feed_id"`
+		ItemsSeen  int       `json:"items_seen"`
+		NewPosts   int       `json:"new_posts"`
+		StatusCode *int      `json:"status_code"`
+		Error      *string   `json:"error"`
+	}
+
+	// Run the fetch in the request, bounded like any other fetch. It goes on if the client hangs up,
+	// so the job it claimed is always completed or rescheduled.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), fetchTimeout)
+	defer cancel()
+
+	// Leasing the feed's job keeps the workers away from it while we fetch, and tells us if one is already at it
+	job, err := apiCfg.Scraper.claimFeed(ctx, feed.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "Feed is being fetched right now, try again later")
+		return
+	}
+	if err != nil {
+		log.Printf("Error claiming feed for refresh: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to refresh feed")
+		return
+	}
+	result, err := apiCfg.Scraper.scrapeJobFeed(ctx, job, feed)
+
+	resp := refreshResponse{
+		FeedID:    feed.ID,
+		ItemsSeen: result.ItemsSeen,
+		NewPosts:  len(result.NewPosts),
+	}
+	if result.Meta.StatusCode != 0 {
+		resp.StatusCode = &result.Meta.StatusCode
+	}
+
+	// The feed itself failed us, so report it as a bad gateway along with what we know
+	var upstreamErr upstreamError
+	if errors.As(err, &upstreamErr) {
+		msg := err.Error()
+		resp.Error = &msg
+		respondwithJSON(w, http.StatusBadGateway, resp)
+		return
+	}
+	if err != nil {
+		log.Printf("Error refreshing feed: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to refresh feed")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, resp)
+}
+
+// handlerGetFeedFetches returns the most recent fetch attempts of a feed, newest first.
+func (apiCfg *apiConfig) handlerGetFeedFetches(w http.ResponseWriter, r *http.Request, user database.User) {
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n < 1 || n > 500 {
+			respondWithError(w, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		limit = n
+	}
+
+	// Tell a feed without any history apart from one that doesn't exist
+	feed, ok := apiCfg.feedFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	fetches, err := apiCfg.DB.GetFeedFetches(r.Context(), database.GetFeedFetchesParams{
+		FeedID: feed.ID,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		log.Printf("Error getting feed fetches: %v", err) // Log error
+		respondWithError(w, http.StatusInternalServerError, "Unable to get feed fetches")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseFeedFetchesToFeedFetches(fetches))
+}
+
+// handlerGetFeed returns a single feed.
+func (apiCfg *apiConfig) handlerGetFeed(w http.ResponseWriter, r *http.Request) {
+	feed, ok := apiCfg.feedFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseFeedToFeed(feed))
+}
+
+// handlerUpdateFeed lets a feed's owner rename it or point it at a new URL. Missing fields are left as they are.
+func (apiCfg *apiConfig) handlerUpdateFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Name *string `json:"name"`
+		URL  *string `json:"url"`
+	}
+
+	feed, ok := apiCfg.feedFromURL(w, r)
+	if !ok {
+		return
+	}
+	// Everyone following the feed sees the change, so only its owner may make it
+	if !feed.UserID.Valid || feed.UserID.UUID != user.ID {
+		respondWithError(w, http.StatusForbidden, "Only the feed's owner can change it")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	name, feedURL := feed.Name, feed.Url
+	if params.Name != nil {
+		name = strings.TrimSpace(*params.Name)
+		if name == "" {
+			respondWithError(w, http.StatusBadRequest, "name must not be empty")
+			return
+		}
+	}
+	if params.URL != nil {
+		feedURL, err = normalizeFeedURL(*params.URL)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	// A new URL is fetched again straight away
+	updated, err := apiCfg.DB.UpdateFeed(r.Context(), database.UpdateFeedParams{
+		Name:      name,
+		Url:       feedURL,
+		UpdatedAt: time.Now().UTC(),
+		ID:        feed.ID,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Another feed already has that URL")
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Feed not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating feed: %v", err) // Log error
+		respondWithError(w, http.StatusInternalServerError, "Unable to update feed")
+		return
+	}
+
+	// A feed that was given up on gets another chance at its new URL
+	if updated.Url != feed.Url {
+		err = apiCfg.DB.DeleteDeadScrapeJobForFeed(r.Context(), feed.ID)
+		if err != nil {
+			log.Printf("Error clearing dead scrape job: %v", err)
+		}
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseFeedToFeed(updated))
+}
+
+// handlerDeleteFeed deletes a feed along with its posts and follows. Its owner and admins may do this.
+func (apiCfg *apiConfig) handlerDeleteFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	feed, ok := apiCfg.feedFromURL(w, r)
+	if !ok {
+		return
+	}
+	if !canManageFeed(user, feed) {
+		respondWithError(w, http.StatusForbidden, "Only the feed's owner can delete it")
+		return
+	}
+
+	err := apiCfg.DB.DeleteFeed(r.Context(), feed.ID)
+	if err != nil {
+		log.Printf("Error deleting feed: %v", err) // Log error
+		respondWithError(w, http.StatusInternalServerError, "Unable to delete feed")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, map[string]string{"message": "Feed deleted successfully"})
+}
+
+// feedFromURL loads the feed named by the {feedID} URL parameter.
+// If it can't, it has already written the error response and returns false.
+func (apiCfg *apiConfig) feedFromURL(w http.ResponseWriter, r *http.Request) (database.Feed, bool) {
+	feedID, err := uuid.Parse(chi.URLParam(r, "feedID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid feedID")
+		return database.Feed{}, false
+	}
+
+	feed, err := apiCfg.DB.GetFeedByID(r.Context(), feedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Feed not found")
+		return database.Feed{}, false
+	}
+	if err != nil {
+		log.Printf("Error getting feed: %v", err) // Log error
+		respondWithError(w, http.StatusInternalServerError, "Unable to get feed")
+		return database.Feed{}, false
+	}
+
+	return feed, true
+}
+
+// canManageFeed reports whether user may change a feed's settings: its owner and admins can.
+func canManageFeed(user database.User, feed database.Feed) bool {
+	return user.IsAdmin || (feed.UserID.Valid && feed.UserID.UUID == user.ID)
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"time"
@@ -60,45 +62,111 @@ func (apiCfg *apiConfig) handlerGetFeedFollows(w http.ResponseWriter, r *http.Re
 	respondwithJSON(w, http.StatusOK, databaseFeedFollowsToFeedFollows(feedFollows))
 }
 
-// Handler to delete a feed follow for a user
+// feedFollowFromURL looks up the {feedFollowID} path parameter among the user's follows.
+// It writes the error response itself and returns false if the follow can't be used.
+func (apiCfg *apiConfig) feedFollowFromURL(w http.ResponseWriter, r *http.Request, user database.User) (database.FeedFollow, bool) {
+	feedFollowID, err := uuid.Parse(chi.URLParam(r, "feedFollowID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid feedFollowID")
+		return database.FeedFollow{}, false
+	}
+
+	feedFollow, err := apiCfg.DB.GetFeedFollowByID(r.Context(), database.GetFeedFollowByIDParams{
+		ID:     feedFollowID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Feed follow not found")
+		return database.FeedFollow{}, false
+	}
+	if err != nil {
+		log.Printf("Error getting feed follow: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to get feed follow")
+		return database.FeedFollow{}, false
+	}
+	return feedFollow, true
+}
+
+// Handler to get one of the user's feed follows by its ID
+func (apiCfg *apiConfig) handlerGetFeedFollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedFollow, ok := apiCfg.feedFollowFromURL(w, r, user)
+	if !ok {
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseFeedFollowToFeedFollow(feedFollow))
+}
+
+// Handler to delete one of the user's feed follows by its ID.
+// The feed itself stays; the pruning job cleans it up once nobody has followed it for a while.
 func (apiCfg *apiConfig) handlerDeleteFeedFollow(w http.ResponseWriter, r *http.Request, user database.User) {
-	// Extract feedFollowID from the URL
-	feedFollowIDStr := chi.URLParam(r, "feedFollowID")
-	feedFollowID, err := uuid.Parse(feedFollowIDStr)
+	feedFollowID, err := uuid.Parse(chi.URLParam(r, "feedFollowID"))
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid feedFollowID")
 		return
 	}
 
-	// Delete the feed follow entry
-	err = apiCfg.DB.DeleteFeedFollow(r.Context(), database.DeleteFeedFollowParams{
+	deleted, err := apiCfg.DB.DeleteFeedFollowByID(r.Context(), database.DeleteFeedFollowByIDParams{
+		ID:     feedFollowID,
 		UserID: user.ID,
-		FeedID: feedFollowID,
 	})
 	if err != nil {
 		log.Printf("Error deleting feed follow: %v", err)
 		respondWithError(w, http.StatusInternalServerError, "Unable to delete feed follow")
 		return
 	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Feed follow not found")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, map[string]string{"message": "Feed follow deleted successfully"})
+}
+
+// Handler to get the user's follow of the feed in the URL, 404 if they don't follow it
+func (apiCfg *apiConfig) handlerGetFollowForFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	feed, ok := apiCfg.feedFromURL(w, r)
+	if !ok {
+		return
+	}
 
-	// Check if there are any remaining followers for the feed
-	count, err := apiCfg.DB.CountFeedFollows(r.Context(), feedFollowID)
+	feedFollow, err := apiCfg.DB.GetFeedFollowForFeed(r.Context(), database.GetFeedFollowForFeedParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Not following this feed")
+		return
+	}
 	if err != nil {
-		log.Printf("Error counting feed follows: %v", err)
-		respondWithError(w, http.StatusInternalServerError, "Error checking remaining follows")
+		log.Printf("Error getting feed follow: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to get feed follow")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseFeedFollowToFeedFollow(feedFollow))
+}
+
+// Handler to unfollow the feed in the URL
+func (apiCfg *apiConfig) handlerUnfollowFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	feed, ok := apiCfg.feedFromURL(w, r)
+	if !ok {
 		return
 	}
 
-	// If no followers remain, delete the feed itself
-	if count == 0 {
-		err = apiCfg.DB.DeleteFeed(r.Context(), feedFollowID)
-		if err != nil {
-			log.Printf("Error deleting feed: %v", err)
-			respondWithError(w, http.StatusInternalServerError, "Unable to delete feed")
-			return
-		}
+	deleted, err := apiCfg.DB.DeleteFeedFollow(r.Context(), database.DeleteFeedFollowParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+	})
+	if err != nil {
+		log.Printf("Error deleting feed follow: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to delete feed follow")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Not following this feed")
+		return
 	}
 
-	// Return success message
-	respondwithJSON(w, http.StatusOK, map[string]string{"message": "Feed follow deleted successfully"})
+	respondwithJSON(w, http.StatusOK, map[string]string{"message": "Feed unfollowed successfully"})
 }
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	// filterRuleMaxPatternLength bounds a pattern, since every rule runs against every post in a timeline
+	filterRuleMaxPatternLength = 200
+	// filterRuleMaxPerUser bounds how many rules one user can make the timeline query run
+	filterRuleMaxPerUser = 100
+)
+
+// filterRuleFields are the parts of a post a filter rule can match against.
+var filterRuleFields = map[string]bool{"title": true, "description": true, "author": true, "category": true}
+
+// handlerCreateFilterRule adds a rule hiding posts from the caller's timeline.
+// A keyword matches case-insensitively anywhere in the field, a regex uses Postgres' case-insensitive ~*.
+// With feed_id the rule only applies to that feed's posts.
+func (apiCfg *apiConfig) handlerCreateFilterRule(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		FeedID    *uuid.UUID `json:"feed_id"`
+		Field     string     `json:"field"`
+		MatchType string     `json:"match_type"`
+		Pattern   string     `json:"pattern"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if !filterRuleFields[params.Field] {
+		respondWithError(w, http.StatusBadRequest, "field must be title, description, author or category")
+		return
+	}
+	if params.MatchType == "" {
+		params.MatchType = "keyword"
+	}
+	if params.MatchType != "keyword" && params.MatchType != "regex" {
+		respondWithError(w, http.StatusBadRequest, "match_type must be keyword or regex")
+		return
+	}
+	if params.MatchType == "keyword" {
+		params.Pattern = strings.TrimSpace(params.Pattern)
+	}
+	if params.Pattern == "" {
+		respondWithError(w, http.StatusBadRequest, "pattern is required")
+		return
+	}
+	if utf8.RuneCountInString(params.Pattern) > filterRuleMaxPatternLength {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("pattern can be at most %d characters", filterRuleMaxPatternLength))
+		return
+	}
+
+	count, err := apiCfg.DB.CountFilterRulesForUser(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("Error counting filter rules: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to create filter rule")
+		return
+	}
+	if count >= filterRuleMaxPerUser {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("You can have at most %d filter rules", filterRuleMaxPerUser))
+		return
+	}
+
+	// Postgres runs the regexes, so it is the one to ask whether a pattern compiles
+	if params.MatchType == "regex" {
+		err = apiCfg.DB.CheckFilterRegex(r.Context(), params.Pattern)
+		if isInvalidRegex(err) {
+			respondWithError(w, http.StatusBadRequest, "pattern is not a valid regular expression")
+			return
+		}
+		if err != nil {
+			log.Printf("Error checking filter regex: %v", err)
+			respondWithError(w, http.StatusInternalServerError, "Unable to create filter rule")
+			return
+		}
+	}
+
+	feedID := uuid.NullUUID{}
+	if params.FeedID != nil {
+		_, err = apiCfg.DB.GetFeedByID(r.Context(), *params.FeedID)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusBadRequest, "feed_id does not match a feed")
+			return
+		}
+		if err != nil {
+			log.Printf("Error getting feed for filter rule: %v", err)
+			respondWithError(w, http.StatusInternalServerError, "Unable to create filter rule")
+			return
+		}
+		feedID = uuid.NullUUID{UUID: *params.FeedID, Valid: true}
+	}
+
+	rule, err := apiCfg.DB.CreateFilterRule(r.Context(), database.CreateFilterRuleParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		FeedID:    feedID,
+		Field:     params.Field,
+		MatchType: params.MatchType,
+		Pattern:   params.Pattern,
+	})
+	if err != nil {
+		log.Printf("Error creating filter rule: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to create filter rule")
+		return
+	}
+
+	// A new rule hasn't been counted yet; listing the rules gives its suppressed_count
+	respondwithJSON(w, http.StatusOK, databaseFilterRuleToFilterRule(rule, 0))
+}
+
+// handlerGetFilterRules lists the caller's filter rules with how many posts each one hides.
+func (apiCfg *apiConfig) handlerGetFilterRules(w http.ResponseWriter, r *http.Request, user database.User) {
+	rows, err := apiCfg.DB.GetFilterRulesWithCounts(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("Error getting filter rules: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to get filter rules")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseFilterRuleRowsToFilterRules(rows))
+}
+
+// handlerDeleteFilterRule deletes one of the caller's filter rules, bringing back the posts it hid.
+func (apiCfg *apiConfig) handlerDeleteFilterRule(w http.ResponseWriter, r *http.Request, user database.User) {
+	ruleID, err := uuid.Parse(chi.URLParam(r, "ruleID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ruleID")
+		return
+	}
+
+	deleted, err := apiCfg.DB.DeleteFilterRule(r.Context(), database.DeleteFilterRuleParams{
+		ID:     ruleID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		log.Printf("Error deleting filter rule: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to delete filter rule")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Filter rule not found")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, map[string]string{"message": "Filter rule deleted successfully"})
+}
+
+// isInvalidRegex reports whether err is Postgres rejecting a regular expression.
+func isInvalidRegex(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "2201B"
+}
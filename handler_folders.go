@@ -0,0 +1,194 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// handlerCreateFolder creates a folder for organising the caller's feed follows.
+func (apiCfg *apiConfig) handlerCreateFolder(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil || strings.TrimSpace(params.Name) == "" {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	folder, err := apiCfg.DB.CreateFolder(r.Context(), database.CreateFolderParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Name:      strings.TrimSpace(params.Name),
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "A folder with that name already exists")
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating folder: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to create folder")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseFolderToFolder(folder))
+}
+
+// handlerGetFolders lists the caller's folders by name.
+func (apiCfg *apiConfig) handlerGetFolders(w http.ResponseWriter, r *http.Request, user database.User) {
+	folders, err := apiCfg.DB.GetFolders(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("Error getting folders: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to get folders")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseFoldersToFolders(folders))
+}
+
+// handlerRenameFolder renames one of the caller's folders.
+func (apiCfg *apiConfig) handlerRenameFolder(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+
+	folderID, err := uuid.Parse(chi.URLParam(r, "folderID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid folderID")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil || strings.TrimSpace(params.Name) == "" {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	folder, err := apiCfg.DB.RenameFolder(r.Context(), database.RenameFolderParams{
+		ID:        folderID,
+		UserID:    user.ID,
+		Name:      strings.TrimSpace(params.Name),
+		UpdatedAt: time.Now().UTC(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Folder not found")
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "A folder with that name already exists")
+		return
+	}
+	if err != nil {
+		log.Printf("Error renaming folder: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to rename folder")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseFolderToFolder(folder))
+}
+
+// handlerDeleteFolder deletes one of the caller's folders. The follows in it stay, unfiled.
+func (apiCfg *apiConfig) handlerDeleteFolder(w http.ResponseWriter, r *http.Request, user database.User) {
+	folderID, err := uuid.Parse(chi.URLParam(r, "folderID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid folderID")
+		return
+	}
+
+	deleted, err := apiCfg.DB.DeleteFolder(r.Context(), database.DeleteFolderParams{
+		ID:     folderID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		log.Printf("Error deleting folder: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to delete folder")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Folder not found")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, map[string]string{"message": "Folder deleted successfully"})
+}
+
+// handlerSetFeedFollowFolder moves one of the caller's feed follows into a folder, or out of it with a null folder_id.
+func (apiCfg *apiConfig) handlerSetFeedFollowFolder(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		FolderID *uuid.UUID `json:"folder_id"`
+	}
+
+	feedFollowID, err := uuid.Parse(chi.URLParam(r, "feedFollowID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid feedFollowID")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	folderID := uuid.NullUUID{}
+	if params.FolderID != nil {
+		// Only the caller's own folders will do
+		folder, err := apiCfg.DB.GetFolderForUser(r.Context(), database.GetFolderForUserParams{
+			ID:     *params.FolderID,
+			UserID: user.ID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Folder not found")
+			return
+		}
+		if err != nil {
+			log.Printf("Error getting folder: %v", err)
+			respondWithError(w, http.StatusInternalServerError, "Unable to get folder")
+			return
+		}
+		folderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+	}
+
+	feedFollow, err := apiCfg.DB.SetFeedFollowFolder(r.Context(), database.SetFeedFollowFolderParams{
+		ID:        feedFollowID,
+		UserID:    user.ID,
+		FolderID:  folderID,
+		UpdatedAt: time.Now().UTC(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Feed follow not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error moving feed follow: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to move feed follow")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseFeedFollowToFeedFollow(feedFollow))
+}
+
+// isUniqueViolation reports whether err comes from a unique constraint in Postgres.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+	"github.com/google/uuid"
+)
+
+// maxOPMLSize bounds the size of an uploaded OPML document
+const maxOPMLSize = 5 << 20
+
+// handlerExportOPML renders the caller's follows as an OPML 2.0 document, with folders as outline groups.
+func (apiCfg *apiConfig) handlerExportOPML(w http.ResponseWriter, r *http.Request, user database.User) {
+	follows, err := apiCfg.DB.GetFeedFollowsForExport(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("Error getting feed follows for export: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to export feed follows")
+		return
+	}
+
+	entries := []opmlEntry{}
+	for _, follow := range follows {
+		entries = append(entries, opmlEntry{
+			Title:   follow.Name,
+			XMLURL:  follow.Url,
+			HTMLURL: follow.SiteUrl.String,
+			Folder:  follow.FolderName.String,
+		})
+	}
+
+	data, err := xml.MarshalIndent(buildOPML(user.Name+"'s subscriptions", time.Now(), entries), "", "  ")
+	if err != nil {
+		log.Printf("Error rendering OPML: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to export feed follows")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/x-opml; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="subscriptions.opml"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(xml.Header))
+	w.Write(data)
+}
+
+// opmlImportResult reports what happened to one subscription of an imported OPML document.
+type opmlImportResult struct {
+	URL    string     `json:"url"`
+	Title  string     `json:"title"`
+	Folder string     `json:"folder,omitempty"`
+	Status string     `json:"status"` // created, followed, already_following or failed
+	FeedID *uuid.UUID `json:"feed_id,omitempty"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// handlerImportOPML follows every subscription of an OPML document sent as the request body.
+// Feeds we don't have yet are created, and outline groups become folders.
+func (apiCfg *apiConfig) handlerImportOPML(w http.ResponseWriter, r *http.Request, user database.User) {
+	entries, err := parseOPML(http.MaxBytesReader(w, r.Body, maxOPMLSize))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid OPML document")
+		return
+	}
+
+	results := []opmlImportResult{}
+	folders := map[string]uuid.NullUUID{}
+	for _, entry := range entries {
+		result := opmlImportResult{URL: entry.XMLURL, Title: entry.Title, Folder: entry.Folder}
+
+		feedID, status, err := apiCfg.importOPMLEntry(r.Context(), user, entry, folders)
+		if err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+		} else {
+			result.Status = status
+			result.FeedID = &feedID
+		}
+		results = append(results, result)
+	}
+
+	respondwithJSON(w, http.StatusOK, results)
+}
+
+// importOPMLEntry makes sure the feed of an OPML entry exists and that user follows it.
+// folders caches the folders already looked up during this import.
+func (apiCfg *apiConfig) importOPMLEntry(ctx context.Context, user database.User, entry opmlEntry, folders map[string]uuid.NullUUID) (uuid.UUID, string, error) {
+	if _, err := normalizeFeedURL(entry.XMLURL); err != nil {
+		return uuid.Nil, "", err
+	}
+
+	folderID, ok := folders[entry.Folder]
+	if !ok && entry.Folder != "" {
+		folder, err := apiCfg.DB.EnsureFolder(ctx, database.EnsureFolderParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			UserID:    user.ID,
+			Name:      entry.Folder,
+		})
+		if err != nil {
+			log.Printf("Error creating folder: %v", err)
+			return uuid.Nil, "", errors.New("unable to create folder")
+		}
+		folderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+		folders[entry.Folder] = folderID
+	}
+
+	// The site URL is replaced by the channel's own link once the feed is fetched
+	result, err := apiCfg.followFeedURL(ctx, user, entry.XMLURL, entry.Title, entry.HTMLURL, folderID)
+	if err != nil {
+		log.Printf("Error importing feed %s: %v", entry.XMLURL, err)
+		return uuid.Nil, "", errors.New("unable to follow feed")
+	}
+
+	switch {
+	case result.FeedCreated:
+		return result.Feed.ID, "created", nil
+	case result.FollowCreated:
+		return result.Feed.ID, "followed", nil
+	default:
+		return result.Feed.ID, "already_following", nil
+	}
+}
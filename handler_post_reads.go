@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerMarkPostRead marks a single post as read for the caller.
+func (apiCfg *apiConfig) handlerMarkPostRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	post, ok := apiCfg.postFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := apiCfg.DB.MarkPostsRead(r.Context(), database.MarkPostsReadParams{
+		UserID:  user.ID,
+		Now:     time.Now().UTC(),
+		PostIds: []uuid.UUID{post.ID},
+	})
+	if err != nil {
+		log.Printf("Error marking post read: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to mark post read")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, map[string]interface{}{"post_id": post.ID, "read": true})
+}
+
+// handlerMarkPostUnread marks a single post as unread for the caller.
+func (apiCfg *apiConfig) handlerMarkPostUnread(w http.ResponseWriter, r *http.Request, user database.User) {
+	post, ok := apiCfg.postFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := apiCfg.DB.MarkPostsUnread(r.Context(), database.MarkPostsUnreadParams{
+		UserID:  user.ID,
+		PostIds: []uuid.UUID{post.ID},
+	})
+	if err != nil {
+		log.Printf("Error marking post unread: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to mark post unread")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, map[string]interface{}{"post_id": post.ID, "read": false})
+}
+
+// handlerMarkPostsRead marks posts as read in bulk: either the listed post_ids, or everything
+// published up to up_to in the caller's follows, optionally only in one feed.
+func (apiCfg *apiConfig) handlerMarkPostsRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		PostIDs []uuid.UUID `json:"post_ids"`
+		UpTo    *time.Time  `json:"up_to"`
+		FeedID  *uuid.UUID  `json:"feed_id"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if (len(params.PostIDs) > 0) == (params.UpTo != nil) {
+		respondWithError(w, http.StatusBadRequest, "Send either post_ids or up_to")
+		return
+	}
+	if params.FeedID != nil && params.UpTo == nil {
+		respondWithError(w, http.StatusBadRequest, "feed_id only works together with up_to")
+		return
+	}
+
+	var marked int64
+	if params.UpTo != nil {
+		feedID := uuid.NullUUID{}
+		if params.FeedID != nil {
+			feedID = uuid.NullUUID{UUID: *params.FeedID, Valid: true}
+		}
+		marked, err = apiCfg.DB.MarkPostsReadUpTo(r.Context(), database.MarkPostsReadUpToParams{
+			Now:    time.Now().UTC(),
+			UserID: user.ID,
+			UpTo:   params.UpTo.UTC(),
+			FeedID: feedID,
+		})
+	} else {
+		marked, err = apiCfg.DB.MarkPostsRead(r.Context(), database.MarkPostsReadParams{
+			UserID:  user.ID,
+			Now:     time.Now().UTC(),
+			PostIds: params.PostIDs,
+		})
+	}
+	if err != nil {
+		log.Printf("Error marking posts read: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to mark posts read")
+		return
+	}
+
+	// Posts that were already read aren't counted
+	respondwithJSON(w, http.StatusOK, map[string]int64{"marked": marked})
+}
+
+// handlerMarkPostsUnread marks the listed post_ids as unread again.
+func (apiCfg *apiConfig) handlerMarkPostsUnread(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		PostIDs []uuid.UUID `json:"post_ids"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil || len(params.PostIDs) == 0 {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	marked, err := apiCfg.DB.MarkPostsUnread(r.Context(), database.MarkPostsUnreadParams{
+		UserID:  user.ID,
+		PostIds: params.PostIDs,
+	})
+	if err != nil {
+		log.Printf("Error marking posts unread: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to mark posts unread")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, map[string]int64{"marked": marked})
+}
+
+// handlerGetUnreadCounts returns how many unread posts each followed feed has.
+func (apiCfg *apiConfig) handlerGetUnreadCounts(w http.ResponseWriter, r *http.Request, user database.User) {
+	counts, err := apiCfg.DB.GetUnreadCounts(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("Error getting unread counts: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to get unread counts")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseUnreadCountsToUnreadCounts(counts))
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+)
+
+// handlerStarPost stars a post for the caller. Starred posts are never pruned by the retention policy.
+func (apiCfg *apiConfig) handlerStarPost(w http.ResponseWriter, r *http.Request, user database.User) {
+	post, ok := apiCfg.postFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	err := apiCfg.DB.StarPost(r.Context(), database.StarPostParams{
+		UserID:    user.ID,
+		PostID:    post.ID,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("Error starring post: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to star post")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, map[string]interface{}{"post_id": post.ID, "starred": true})
+}
+
+// handlerUnstarPost removes the caller's star from a post.
+func (apiCfg *apiConfig) handlerUnstarPost(w http.ResponseWriter, r *http.Request, user database.User) {
+	post, ok := apiCfg.postFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	err := apiCfg.DB.UnstarPost(r.Context(), database.UnstarPostParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		log.Printf("Error unstarring post: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to unstar post")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, map[string]interface{}{"post_id": post.ID, "starred": false})
+}
+
+// handlerGetStarredPosts lists the caller's starred posts, most recently starred first, a page at a time.
+// Unfollowing a feed keeps its starred posts in this list.
+func (apiCfg *apiConfig) handlerGetStarredPosts(w http.ResponseWriter, r *http.Request, user database.User) {
+	limit := 10
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n < 1 || n > 100 {
+			respondWithError(w, http.StatusBadRequest, "limit must be between 1 and 100")
+			return
+		}
+		limit = n
+	}
+
+	params := database.GetStarredPostsParams{
+		UserID:   user.ID,
+		PageSize: int32(limit) + 1, // One extra row tells us whether there is another page
+	}
+	var cursor *postCursor
+	if cursorStr := r.URL.Query().Get("cursor"); cursorStr != "" {
+		c, err := decodePostCursor(cursorStr)
+		if err != nil || c.Sort != "starred" {
+			respondWithError(w, http.StatusBadRequest, "Invalid cursor")
+			return
+		}
+		cursor = &c
+		params.CursorAt = sql.NullTime{Time: c.At, Valid: true}
+		params.CursorID = c.ID
+		params.Before = c.Before
+	}
+
+	posts, err := apiCfg.DB.GetStarredPosts(r.Context(), params)
+	if err != nil {
+		log.Printf("Error getting starred posts: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to get starred posts")
+		return
+	}
+
+	posts = pageRows(w, r, posts, limit, cursor, func(post database.GetStarredPostsRow) postCursor {
+		return postCursor{At: post.StarredAt, ID: post.Post.ID, Sort: "starred"}
+	})
+
+	respondwithJSON(w, http.StatusOK, databaseStarredRowsToStarredPosts(posts))
+}
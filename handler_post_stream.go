@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+)
+
+// streamCatchUpPageSize is how many missed posts are loaded at a time when a stream resumes
+const streamCatchUpPageSize = 100
+
+// handlerStreamPosts serves GET /v1/posts/stream: a Server-Sent Events stream of new posts from the caller's follows.
+// Every event carries the post's seq as its ID, so a reconnecting client that sends Last-Event-ID
+// (or ?last_event_id= where it can't set headers) first gets the posts it missed.
+func (apiCfg *apiConfig) handlerStreamPosts(w http.ResponseWriter, r *http.Request, user database.User) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var lastSeq int64
+	if lastEventID != "" {
+		seq, err := strconv.ParseInt(strings.TrimSpace(lastEventID), 10, 64)
+		if err != nil || seq < 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid Last-Event-ID")
+			return
+		}
+		lastSeq = seq
+	}
+
+	// Subscribe before catching up, so nothing stored in between slips through
+	sub := apiCfg.Posts.subscribe(user.ID)
+	defer apiCfg.Posts.unsubscribe(sub)
+
+	// A new stream starts at the newest post
+	if lastEventID == "" {
+		seq, err := apiCfg.DB.GetMaxPostSeq(r.Context())
+		if err != nil {
+			log.Printf("Error getting newest post: %v", err)
+			respondWithError(w, http.StatusInternalServerError, "Unable to start stream")
+			return
+		}
+		lastSeq = seq
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx from holding events back
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	flusher.Flush()
+
+	// Seqs become visible in commit order (see createPostsInSeqOrder), so everything
+	// after lastSeq is exactly what the client hasn't seen yet
+	catchUp := func() error {
+		for {
+			posts, err := apiCfg.DB.GetPostsForUserAfterSeq(r.Context(), database.GetPostsForUserAfterSeqParams{
+				UserID: user.ID,
+				Seq:    lastSeq,
+				Limit:  streamCatchUpPageSize,
+			})
+			if err != nil {
+				return err
+			}
+			for _, post := range posts {
+				err = writePostEvent(w, post)
+				if err != nil {
+					return err
+				}
+				lastSeq = post.Seq
+			}
+			flusher.Flush()
+			if len(posts) < streamCatchUpPageSize {
+				return nil
+			}
+		}
+	}
+	if lastEventID != "" {
+		err := catchUp()
+		if err != nil {
+			log.Printf("Error catching up post stream: %v", err)
+			return
+		}
+	}
+
+	// A comment now and then keeps proxies from closing an idle connection
+	heartbeat := time.NewTicker(30 * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-apiCfg.Posts.done:
+			return
+		case <-heartbeat.C:
+			_, err := fmt.Fprint(w, ": ping\n\n")
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-sub.Resync:
+			err := catchUp()
+			if err != nil {
+				log.Printf("Error catching up post stream: %v", err)
+				return
+			}
+		case post := <-sub.Posts:
+			// Posts stored while catching up arrive here as well
+			if post.Seq <= lastSeq {
+				continue
+			}
+			err := writePostEvent(w, post)
+			if err != nil {
+				return
+			}
+			lastSeq = post.Seq
+			flusher.Flush()
+		}
+	}
+}
+
+// writePostEvent writes one post as an SSE event.
+func writePostEvent(w http.ResponseWriter, post database.Post) error {
+	data, err := json.Marshal(databasePosttoPost(post))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: post\ndata: %s\n\n", post.Seq, data)
+	return err
+}
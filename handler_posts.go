@@ -0,0 +1,119 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// handlerSearchPosts runs a full-text search over the posts of the caller's followed feeds, best matches first.
+// Phrases, prefixes, exclusions and OR are supported, see buildTSQuery.
+func (apiCfg *apiConfig) handlerSearchPosts(w http.ResponseWriter, r *http.Request, user database.User) {
+	tsQuery := buildTSQuery(r.URL.Query().Get("q"))
+	if tsQuery == "" {
+		respondWithError(w, http.StatusBadRequest, "q must contain at least one word")
+		return
+	}
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n < 1 || n > 100 {
+			respondWithError(w, http.StatusBadRequest, "limit must be between 1 and 100")
+			return
+		}
+		limit = n
+	}
+	// Ranked results can't be paged by cursor, so search pages by offset
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		n, err := strconv.Atoi(offsetStr)
+		if err != nil || n < 0 {
+			respondWithError(w, http.StatusBadRequest, "offset can't be negative")
+			return
+		}
+		offset = n
+	}
+
+	rows, err := apiCfg.DB.SearchPostsForUser(r.Context(), database.SearchPostsForUserParams{
+		Query:      tsQuery,
+		UserID:     user.ID,
+		PageSize:   int32(limit) + 1, // One extra row tells us whether there is another page
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		log.Printf("Error searching posts: %v", err) // Log error
+		respondWithError(w, http.StatusInternalServerError, "Unable to search posts")
+		return
+	}
+
+	links := []string{}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(r, "offset", strconv.Itoa(offset+limit))))
+	}
+	if offset > 0 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(r, "offset", strconv.Itoa(max(offset-limit, 0)))))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseSearchRowsToPostSearchResults(rows))
+}
+
+// handlerGetPostDuplicates lists every stored copy of a post, across all feeds, oldest first.
+// The timeline only shows the oldest copy from the caller's follows. Only posts from followed feeds can be looked up.
+func (apiCfg *apiConfig) handlerGetPostDuplicates(w http.ResponseWriter, r *http.Request, user database.User) {
+	post, ok := apiCfg.postFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	duplicates, err := apiCfg.DB.GetPostDuplicates(r.Context(), database.GetPostDuplicatesParams{
+		ID:     post.ID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		log.Printf("Error getting post duplicates: %v", err) // Log error
+		respondWithError(w, http.StatusInternalServerError, "Unable to get post duplicates")
+		return
+	}
+	// The post itself is always among its duplicates, so no rows means the caller doesn't follow its feed
+	if len(duplicates) == 0 {
+		respondWithError(w, http.StatusNotFound, "Post not found")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseDuplicateRowsToTimelinePosts(duplicates))
+}
+
+// postFromURL loads the post named by the {postID} path parameter, responding with an error if it can't.
+func (apiCfg *apiConfig) postFromURL(w http.ResponseWriter, r *http.Request) (database.Post, bool) {
+	postID, err := uuid.Parse(chi.URLParam(r, "postID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid postID")
+		return database.Post{}, false
+	}
+
+	post, err := apiCfg.DB.GetPostByID(r.Context(), postID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Post not found")
+		return database.Post{}, false
+	}
+	if err != nil {
+		log.Printf("Error getting post: %v", err) // Log error
+		respondWithError(w, http.StatusInternalServerError, "Unable to get post")
+		return database.Post{}, false
+	}
+
+	return post, true
+}
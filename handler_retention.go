@@ -0,0 +1,108 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+)
+
+// handlerGetFeedRetention returns a feed's retention override, or 404 if it uses the global policy.
+func (apiCfg *apiConfig) handlerGetFeedRetention(w http.ResponseWriter, r *http.Request, user database.User) {
+	feed, ok := apiCfg.feedFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	retention, err := apiCfg.DB.GetFeedRetention(r.Context(), feed.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Feed uses the default retention policy")
+		return
+	}
+	if err != nil {
+		log.Printf("Error getting feed retention: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to get feed retention")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseFeedRetentionToFeedRetention(retention))
+}
+
+// handlerPutFeedRetention sets a feed's retention override. Only the feed's owner or an admin may do this.
+func (apiCfg *apiConfig) handlerPutFeedRetention(w http.ResponseWriter, r *http.Request, user database.User) {
+	// A missing or null field falls back to the global default, 0 keeps everything
+	type parameters struct {
+		MaxAgeDays *int32 `json:"max_age_days"`
+		MaxPosts   *int32 `json:"max_posts"`
+	}
+
+	feed, ok := apiCfg.feedFromURL(w, r)
+	if !ok {
+		return
+	}
+	if !canManageFeed(user, feed) {
+		respondWithError(w, http.StatusForbidden, "Only the feed's owner can change its retention")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if (params.MaxAgeDays != nil && *params.MaxAgeDays < 0) || (params.MaxPosts != nil && *params.MaxPosts < 0) {
+		respondWithError(w, http.StatusBadRequest, "max_age_days and max_posts must not be negative")
+		return
+	}
+
+	maxAgeDays := sql.NullInt32{}
+	if params.MaxAgeDays != nil {
+		maxAgeDays = sql.NullInt32{Int32: *params.MaxAgeDays, Valid: true}
+	}
+	maxPosts := sql.NullInt32{}
+	if params.MaxPosts != nil {
+		maxPosts = sql.NullInt32{Int32: *params.MaxPosts, Valid: true}
+	}
+
+	retention, err := apiCfg.DB.UpsertFeedRetention(r.Context(), database.UpsertFeedRetentionParams{
+		FeedID:     feed.ID,
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
+		MaxAgeDays: maxAgeDays,
+		MaxPosts:   maxPosts,
+	})
+	if err != nil {
+		log.Printf("Error setting feed retention: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to set feed retention")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseFeedRetentionToFeedRetention(retention))
+}
+
+// handlerDeleteFeedRetention removes a feed's retention override so it follows the global policy again.
+func (apiCfg *apiConfig) handlerDeleteFeedRetention(w http.ResponseWriter, r *http.Request, user database.User) {
+	feed, ok := apiCfg.feedFromURL(w, r)
+	if !ok {
+		return
+	}
+	if !canManageFeed(user, feed) {
+		respondWithError(w, http.StatusForbidden, "Only the feed's owner can change its retention")
+		return
+	}
+
+	err := apiCfg.DB.DeleteFeedRetention(r.Context(), feed.ID)
+	if err != nil {
+		log.Printf("Error deleting feed retention: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to delete feed retention")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, map[string]string{"message": "Feed retention reset to the default policy"})
+}
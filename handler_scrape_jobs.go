@@ -0,0 +1,69 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// Handler to list scrape jobs by status, dead-lettered ones by default
+func (apiCfg *apiConfig) handlerGetScrapeJobs(w http.ResponseWriter, r *http.Request, user database.User) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "dead"
+	}
+	if status != "pending" && status != "running" && status != "dead" {
+		respondWithError(w, http.StatusBadRequest, "status must be one of pending, running or dead")
+		return
+	}
+
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n < 1 || n > 1000 {
+			respondWithError(w, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+		limit = n
+	}
+
+	jobs, err := apiCfg.DB.ListScrapeJobs(r.Context(), database.ListScrapeJobsParams{
+		Status: status,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		log.Printf("Error listing scrape jobs: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to list scrape jobs")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseScrapeJobsToScrapeJobs(jobs))
+}
+
+// Handler to put a dead-lettered scrape job back in the queue with a fresh set of attempts
+func (apiCfg *apiConfig) handlerRetryScrapeJob(w http.ResponseWriter, r *http.Request, user database.User) {
+	jobID, err := uuid.Parse(chi.URLParam(r, "jobID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid jobID")
+		return
+	}
+
+	job, err := apiCfg.DB.RetryDeadScrapeJob(r.Context(), jobID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "No dead scrape job with that ID")
+		return
+	}
+	if err != nil {
+		log.Printf("Error retrying scrape job: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to retry scrape job")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseScrapeJobToScrapeJob(job))
+}
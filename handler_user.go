@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/PuneethM06/rssagg/internal/database" // Importing database package
@@ -54,19 +60,177 @@ func (apiCfg *apiConfig) handlerGetUser(w http.ResponseWriter, r *http.Request,
 }
 
 // 🔹 Handler to Retrieve Posts for a Specific User
+// Posts come a page at a time: the Link header carries the cursors for the next and previous pages.
 func (apiCfg *apiConfig) handlerGetPostsForUser(w http.ResponseWriter, r *http.Request, user database.User) {
-	// Fetch posts from the database for the given user
-	posts, err := apiCfg.DB.GetPostsForUser(r.Context(), database.GetPostsForUserParams{ // context is used for cancellng the database query in case it timeouts or user closes the request.
-		UserID: user.ID, // Fetch posts only for the authenticated user
-		Limit:  10,      // Limit the results to the latest 10 posts
-	})
+	params, limit, cursor, err := timelineParams(r, user)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
+	// Fetch posts from the database for the given user
+	posts, err := apiCfg.timelinePosts(r.Context(), params) // context is used for cancellng the database query in case it timeouts or user closes the request.
 	if err != nil {
 		log.Printf("Error getting posts for user: %v", err)
 		respondWithError(w, http.StatusInternalServerError, "Unable to get posts for user")
 		return
 	}
 
+	posts = pageRows(w, r, posts, limit, cursor, func(post database.GetPostsForUserRow) postCursor {
+		at := post.Post.PublishedAt
+		if params.SortByIngested {
+			at = post.Post.CreatedAt
+		}
+		return postCursor{At: at, ID: post.Post.ID, Sort: r.URL.Query().Get("sort")}
+	})
+
 	// Return the posts in JSON format
-	respondwithJSON(w, http.StatusOK, databasePostsToPosts(posts))
+	respondwithJSON(w, http.StatusOK, databaseTimelineRowsToTimelinePosts(posts))
+}
+
+// timelineQuery holds the options of a GET /v1/posts request. The filters and paging go into the query params,
+// the sort picks which of the timeline queries runs them: each has a plain ORDER BY that an index can serve.
+type timelineQuery struct {
+	database.GetPostsForUserParams
+	SortByIngested bool // Sort by created_at rather than published_at
+	Ascending      bool // Oldest posts first
+}
+
+// timelinePosts runs the timeline query matching the sort of q.
+// The queries only differ in their ordering, so their params and rows convert into each other.
+func (apiCfg *apiConfig) timelinePosts(ctx context.Context, q timelineQuery) ([]database.GetPostsForUserRow, error) {
+	if !q.SortByIngested && !q.Ascending {
+		return apiCfg.DB.GetPostsForUser(ctx, q.GetPostsForUserParams)
+	}
+
+	posts := []database.GetPostsForUserRow{}
+	switch {
+	case !q.SortByIngested:
+		rows, err := apiCfg.DB.GetPostsForUserOldestFirst(ctx, database.GetPostsForUserOldestFirstParams(q.GetPostsForUserParams))
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			posts = append(posts, database.GetPostsForUserRow(row))
+		}
+	case !q.Ascending:
+		rows, err := apiCfg.DB.GetPostsForUserByIngested(ctx, database.GetPostsForUserByIngestedParams(q.GetPostsForUserParams))
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			posts = append(posts, database.GetPostsForUserRow(row))
+		}
+	default:
+		rows, err := apiCfg.DB.GetPostsForUserByIngestedOldestFirst(ctx, database.GetPostsForUserByIngestedOldestFirstParams(q.GetPostsForUserParams))
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			posts = append(posts, database.GetPostsForUserRow(row))
+		}
+	}
+	return posts, nil
+}
+
+// timelineParams reads the paging, filtering and sorting options of GET /v1/posts from the query string.
+// The returned params ask for one post more than the page size, which tells us whether there is another page.
+func timelineParams(r *http.Request, user database.User) (timelineQuery, int, *postCursor, error) {
+	query := r.URL.Query()
+	params := timelineQuery{}
+	params.UserID = user.ID // Fetch posts only for the authenticated user
+
+	limit := 10
+	if limitStr := query.Get("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n < 1 || n > 100 {
+			return params, 0, nil, errors.New("limit must be between 1 and 100")
+		}
+		limit = n
+	}
+	params.PageSize = int32(limit) + 1
+
+	switch query.Get("unread_only") {
+	case "", "false":
+	case "true":
+		params.UnreadOnly = true
+	default:
+		return params, 0, nil, errors.New("unread_only must be true or false")
+	}
+
+	// Posts matching the user's filter rules are left out unless asked for, and marked hidden then
+	switch query.Get("show_hidden") {
+	case "", "false":
+	case "true":
+		params.ShowHidden = true
+	default:
+		return params, 0, nil, errors.New("show_hidden must be true or false")
+	}
+
+	// feed_id may be repeated or hold a comma separated list
+	for _, value := range query["feed_id"] {
+		for _, idStr := range strings.Split(value, ",") {
+			feedID, err := uuid.Parse(strings.TrimSpace(idStr))
+			if err != nil {
+				return params, 0, nil, errors.New("Invalid feed_id")
+			}
+			params.FeedIds = append(params.FeedIds, feedID)
+		}
+	}
+
+	if folderStr := query.Get("folder_id"); folderStr != "" {
+		folderID, err := uuid.Parse(folderStr)
+		if err != nil {
+			return params, 0, nil, errors.New("Invalid folder_id")
+		}
+		params.FolderID = uuid.NullUUID{UUID: folderID, Valid: true}
+	}
+
+	switch query.Get("sort") {
+	case "", "published":
+	case "ingested":
+		params.SortByIngested = true
+	default:
+		return params, 0, nil, errors.New("sort must be published or ingested")
+	}
+
+	switch query.Get("order") {
+	case "", "desc":
+	case "asc":
+		params.Ascending = true
+	default:
+		return params, 0, nil, errors.New("order must be asc or desc")
+	}
+
+	// since and until bound the date the posts are sorted by
+	for _, bound := range []struct {
+		name  string
+		value *sql.NullTime
+	}{
+		{"since", &params.Since},
+		{"until", &params.Until},
+	} {
+		if str := query.Get(bound.name); str != "" {
+			t, err := time.Parse(time.RFC3339, str)
+			if err != nil {
+				return params, 0, nil, fmt.Errorf("%s must be an RFC 3339 timestamp", bound.name)
+			}
+			*bound.value = sql.NullTime{Time: t.UTC(), Valid: true}
+		}
+	}
+
+	var cursor *postCursor
+	if cursorStr := query.Get("cursor"); cursorStr != "" {
+		c, err := decodePostCursor(cursorStr)
+		if err != nil || c.Sort != query.Get("sort") {
+			return params, 0, nil, errors.New("Invalid cursor")
+		}
+		cursor = &c
+		params.CursorAt = sql.NullTime{Time: c.At, Valid: true}
+		params.CursorID = c.ID
+		// Paging backwards walks the list against its order
+		params.Ascending = params.Ascending != c.Before
+	}
+
+	return params, limit, cursor, nil
 }
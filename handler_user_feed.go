@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// outboundFeedDefaultLimit is how many posts an outbound feed holds when the URL doesn't say
+const outboundFeedDefaultLimit = 50
+
+// handlerGetFeedToken returns the user's feed token and the feed URLs built from it, 404 if there is none.
+func (apiCfg *apiConfig) handlerGetFeedToken(w http.ResponseWriter, r *http.Request, user database.User) {
+	if !user.FeedToken.Valid {
+		respondWithError(w, http.StatusNotFound, "Outbound feeds are not enabled")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, userFeedToken(r, user))
+}
+
+// handlerRotateFeedToken creates a new feed token, which also turns the outbound feeds on.
+// URLs handed out with the old token stop working.
+func (apiCfg *apiConfig) handlerRotateFeedToken(w http.ResponseWriter, r *http.Request, user database.User) {
+	user, err := apiCfg.DB.RotateUserFeedToken(r.Context(), database.RotateUserFeedTokenParams{
+		ID:        user.ID,
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("Error rotating feed token: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to rotate feed token")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, userFeedToken(r, user))
+}
+
+// handlerRevokeFeedToken removes the feed token, switching the outbound feeds off.
+func (apiCfg *apiConfig) handlerRevokeFeedToken(w http.ResponseWriter, r *http.Request, user database.User) {
+	err := apiCfg.DB.ClearUserFeedToken(r.Context(), database.ClearUserFeedTokenParams{
+		ID:        user.ID,
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("Error revoking feed token: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to revoke feed token")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, map[string]string{"message": "Feed token revoked"})
+}
+
+// handlerGetUserFeed serves GET /v1/users/{userID}/feed.{format}?token=...
+// It is authenticated by the token in the URL since feed readers can't send our Authorization header.
+// The timeline filters (feed_id, folder_id, unread_only, sort, since, until, limit) apply,
+// and q turns it into a saved search: only matching posts, still newest first so readers see new matches.
+func (apiCfg *apiConfig) handlerGetUserFeed(w http.ResponseWriter, r *http.Request) {
+	contentType, ok := outboundFeedFormats[chi.URLParam(r, "format")]
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Unknown feed format")
+		return
+	}
+
+	// Unknown users and wrong tokens look the same from outside
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Feed not found")
+		return
+	}
+	user, err := apiCfg.DB.GetUserByID(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Feed not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error getting user: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to get feed")
+		return
+	}
+	token := r.URL.Query().Get("token")
+	if !user.FeedToken.Valid || token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(user.FeedToken.String)) != 1 {
+		respondWithError(w, http.StatusNotFound, "Feed not found")
+		return
+	}
+
+	selfURL := absoluteURL(r, r.URL.RequestURI())
+
+	// The cursor belongs to the JSON timeline, a feed is always its newest page
+	query := r.URL.Query()
+	query.Del("cursor")
+	if query.Get("limit") == "" {
+		query.Set("limit", strconv.Itoa(outboundFeedDefaultLimit))
+	}
+	r.URL.RawQuery = query.Encode()
+	params, limit, _, err := timelineParams(r, user)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if q := query.Get("q"); q != "" {
+		tsQuery := buildTSQuery(q)
+		if tsQuery == "" {
+			respondWithError(w, http.StatusBadRequest, "q must contain at least one word")
+			return
+		}
+		params.SearchQuery = sql.NullString{String: tsQuery, Valid: true}
+	}
+
+	items, err := apiCfg.outboundFeedItems(r.Context(), params, limit)
+	if err != nil {
+		log.Printf("Error getting posts for outbound feed: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to get feed")
+		return
+	}
+
+	feed := outboundFeed{
+		ID:      user.ID,
+		Title:   user.Name + "'s feed",
+		Author:  user.Name,
+		SelfURL: selfURL,
+		Updated: user.UpdatedAt,
+		Items:   items,
+	}
+	for _, item := range items {
+		if item.Updated.After(feed.Updated) {
+			feed.Updated = item.Updated
+		}
+	}
+
+	body, err := renderOutboundFeed(feed, chi.URLParam(r, "format"))
+	if err != nil {
+		log.Printf("Error rendering feed: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to render feed")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// outboundFeedItems loads the posts for an outbound feed, the timeline described by params.
+func (apiCfg *apiConfig) outboundFeedItems(ctx context.Context, params timelineQuery, limit int) ([]outboundItem, error) {
+	posts, err := apiCfg.timelinePosts(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	// timelineParams asks for one extra row to see if there is a next page
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
+
+	items := []outboundItem{}
+	for _, post := range posts {
+		items = append(items, outboundItemFromPost(post.Post))
+	}
+	return items, nil
+}
+
+func outboundItemFromPost(post database.Post) outboundItem {
+	return outboundItem{
+		ID:        post.ID,
+		Title:     post.Title,
+		URL:       post.Url,
+		Summary:   post.Description.String,
+		Content:   post.Content.String,
+		Published: post.PublishedAt,
+		Updated:   post.UpdatedAt,
+	}
+}
+
+// userFeedToken builds the response for the feed token endpoints.
+func userFeedToken(r *http.Request, user database.User) FeedToken {
+	feedURL := func(format string) string {
+		return absoluteURL(r, "/v1/users/"+user.ID.String()+"/feed."+format+"?token="+user.FeedToken.String)
+	}
+	return FeedToken{
+		Token:   user.FeedToken.String,
+		RSSURL:  feedURL("rss"),
+		AtomURL: feedURL("atom"),
+		JSONURL: feedURL("json"),
+	}
+}
+
+// absoluteURL turns a path on this server into a full URL, honouring a proxy's X-Forwarded-Proto.
+func absoluteURL(r *http.Request, path string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + path
+}
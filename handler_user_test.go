@@ -0,0 +1,93 @@
+package main
+
+import (
+	"database/sql"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+	"github.com/google/uuid"
+)
+
+// TestTimelineParams checks how GET /v1/posts query strings turn into query params, and which ones are rejected
+func TestTimelineParams(t *testing.T) {
+	user := database.User{ID: uuid.MustParse("7d444840-9dc0-11d1-b245-5ffdce74fad2")}
+	feedA := uuid.MustParse("9b2e7a4c-0f5e-4f7a-8a3e-2c1d5b6e7f80")
+	feedB := uuid.MustParse("2f1c6a3e-8d4b-4c5a-9e7f-0a1b2c3d4e5f")
+	since := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	cursorAt := time.Date(2024, time.March, 6, 12, 0, 0, 0, time.UTC)
+	ingestedCursor := postCursor{At: cursorAt, ID: feedA, Sort: "ingested"}.encode()
+	backwardsCursor := postCursor{At: cursorAt, ID: feedA, Before: true}.encode()
+
+	tests := []struct {
+		query     string
+		want      timelineQuery
+		wantLimit int
+		wantErr   string
+	}{
+		{
+			query:     "",
+			want:      timelineQuery{GetPostsForUserParams: database.GetPostsForUserParams{UserID: user.ID, PageSize: 11}},
+			wantLimit: 10,
+		},
+		{
+			query:     "limit=100&unread_only=true&show_hidden=true&sort=ingested&order=asc",
+			want:      timelineQuery{GetPostsForUserParams: database.GetPostsForUserParams{UserID: user.ID, PageSize: 101, UnreadOnly: true, ShowHidden: true}, SortByIngested: true, Ascending: true},
+			wantLimit: 100,
+		},
+		{
+			query:     "feed_id=" + feedA.String() + ",%20" + feedB.String() + "&feed_id=" + feedA.String(),
+			want:      timelineQuery{GetPostsForUserParams: database.GetPostsForUserParams{UserID: user.ID, PageSize: 11, FeedIds: []uuid.UUID{feedA, feedB, feedA}}},
+			wantLimit: 10,
+		},
+		{
+			query:     "folder_id=" + feedB.String() + "&since=2024-03-01T01:00:00%2B01:00",
+			want:      timelineQuery{GetPostsForUserParams: database.GetPostsForUserParams{UserID: user.ID, PageSize: 11, FolderID: uuid.NullUUID{UUID: feedB, Valid: true}, Since: sql.NullTime{Time: since, Valid: true}}},
+			wantLimit: 10,
+		},
+		{
+			query:     "sort=ingested&cursor=" + ingestedCursor,
+			want:      timelineQuery{GetPostsForUserParams: database.GetPostsForUserParams{UserID: user.ID, PageSize: 11, CursorAt: sql.NullTime{Time: cursorAt, Valid: true}, CursorID: feedA}, SortByIngested: true},
+			wantLimit: 10,
+		},
+		{
+			// Paging back walks the list the other way round
+			query:     "cursor=" + backwardsCursor,
+			want:      timelineQuery{GetPostsForUserParams: database.GetPostsForUserParams{UserID: user.ID, PageSize: 11, CursorAt: sql.NullTime{Time: cursorAt, Valid: true}, CursorID: feedA}, Ascending: true},
+			wantLimit: 10,
+		},
+		{query: "limit=0", wantErr: "limit must be between 1 and 100"},
+		{query: "limit=101", wantErr: "limit must be between 1 and 100"},
+		{query: "limit=ten", wantErr: "limit must be between 1 and 100"},
+		{query: "unread_only=yes", wantErr: "unread_only must be true or false"},
+		{query: "show_hidden=1", wantErr: "show_hidden must be true or false"},
+		{query: "feed_id=" + feedA.String() + ",nope", wantErr: "Invalid feed_id"},
+		{query: "folder_id=nope", wantErr: "Invalid folder_id"},
+		{query: "sort=title", wantErr: "sort must be published or ingested"},
+		{query: "order=up", wantErr: "order must be asc or desc"},
+		{query: "since=2024-03-01", wantErr: "since must be an RFC 3339 timestamp"},
+		{query: "until=yesterday", wantErr: "until must be an RFC 3339 timestamp"},
+		{query: "cursor=garbage", wantErr: "Invalid cursor"},
+		{query: "cursor=" + ingestedCursor, wantErr: "Invalid cursor"}, // From a list sorted differently
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/v1/posts?"+tt.query, nil)
+		got, limit, _, err := timelineParams(r, user)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("timelineParams(%q) error = %v, expected %q", tt.query, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("timelineParams(%q) error = %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) || limit != tt.wantLimit {
+			t.Errorf("timelineParams(%q) = %+v, %d, expected %+v, %d", tt.query, got, limit, tt.want, tt.wantLimit)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// handlerCreateWebhook registers an endpoint that gets every new post from the caller's follows.
+// feed_ids and keywords narrow that down; the response carries the secret deliveries are signed with.
+func (apiCfg *apiConfig) handlerCreateWebhook(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Url      string      `json:"url"`
+		FeedIDs  []uuid.UUID `json:"feed_ids"`
+		Keywords []string    `json:"keywords"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	u, err := url.Parse(strings.TrimSpace(params.Url))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		respondWithError(w, http.StatusBadRequest, "url must be an absolute http or https URL")
+		return
+	}
+	// Deliveries are checked again when they are sent, in case the host's DNS changes in between
+	err = checkWebhookHost(r.Context(), u.Hostname())
+	if errors.Is(err, errPrivateWebhookTarget) {
+		respondWithError(w, http.StatusBadRequest, "url must point at a public address")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "url host can't be resolved")
+		return
+	}
+
+	// A keyword matches case-insensitively anywhere in a post's title or description
+	feedIDs := []uuid.UUID{}
+	feedIDs = append(feedIDs, params.FeedIDs...)
+	keywords := []string{}
+	for _, keyword := range params.Keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+
+	webhook, err := apiCfg.DB.CreateWebhook(r.Context(), database.CreateWebhookParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Url:       u.String(),
+		FeedIds:   feedIDs,
+		Keywords:  keywords,
+	})
+	if err != nil {
+		log.Printf("Error creating webhook: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to create webhook")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseWebhookToWebhook(webhook))
+}
+
+// handlerGetWebhooks lists the caller's webhooks.
+func (apiCfg *apiConfig) handlerGetWebhooks(w http.ResponseWriter, r *http.Request, user database.User) {
+	webhooks, err := apiCfg.DB.GetWebhooksForUser(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("Error getting webhooks: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to get webhooks")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseWebhooksToWebhooks(webhooks))
+}
+
+// handlerDeleteWebhook deletes one of the caller's webhooks along with its pending deliveries.
+func (apiCfg *apiConfig) handlerDeleteWebhook(w http.ResponseWriter, r *http.Request, user database.User) {
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid webhookID")
+		return
+	}
+
+	deleted, err := apiCfg.DB.DeleteWebhook(r.Context(), database.DeleteWebhookParams{
+		ID:     webhookID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		log.Printf("Error deleting webhook: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to delete webhook")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Webhook not found")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, map[string]string{"message": "Webhook deleted successfully"})
+}
+
+// handlerGetWebhookDeliveries returns the delivery log of one of the caller's webhooks, newest first.
+func (apiCfg *apiConfig) handlerGetWebhookDeliveries(w http.ResponseWriter, r *http.Request, user database.User) {
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid webhookID")
+		return
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 500 {
+			respondWithError(w, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+	}
+
+	webhook, err := apiCfg.DB.GetWebhookForUser(r.Context(), database.GetWebhookForUserParams{
+		ID:     webhookID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Webhook not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error getting webhook: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to get webhook")
+		return
+	}
+
+	deliveries, err := apiCfg.DB.GetWebhookDeliveries(r.Context(), database.GetWebhookDeliveriesParams{
+		WebhookID: webhook.ID,
+		Limit:     int32(limit),
+	})
+	if err != nil {
+		log.Printf("Error getting webhook deliveries: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Unable to get webhook deliveries")
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, databaseWebhookDeliveriesToWebhookDeliveries(deliveries))
+}
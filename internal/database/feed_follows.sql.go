@@ -7,15 +7,23 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
-INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, created_at, updated_at, user_id, feed_id
+INSERT INTO feed_follows (
+        id,
+        created_at,
+        updated_at,
+        user_id,
+        feed_id,
+        folder_id
+    )
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, created_at, updated_at, user_id, feed_id, folder_id
 `
 
 type CreateFeedFollowParams struct {
@@ -24,6 +32,7 @@ type CreateFeedFollowParams struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	FolderID  uuid.NullUUID
 }
 
 func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (FeedFollow, error) {
@@ -33,6 +42,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		arg.UpdatedAt,
 		arg.UserID,
 		arg.FeedID,
+		arg.FolderID,
 	)
 	var i FeedFollow
 	err := row.Scan(
@@ -41,11 +51,55 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.FolderID,
 	)
 	return i, err
 }
 
-const deleteFeedFollow = `-- name: DeleteFeedFollow :exec
+const createFeedFollowIfMissing = `-- name: CreateFeedFollowIfMissing :one
+INSERT INTO feed_follows (
+        id,
+        created_at,
+        updated_at,
+        user_id,
+        feed_id,
+        folder_id
+    )
+VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (user_id, feed_id) DO NOTHING
+RETURNING id, created_at, updated_at, user_id, feed_id, folder_id
+`
+
+type CreateFeedFollowIfMissingParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	FeedID    uuid.UUID
+	FolderID  uuid.NullUUID
+}
+
+func (q *Queries) CreateFeedFollowIfMissing(ctx context.Context, arg CreateFeedFollowIfMissingParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, createFeedFollowIfMissing,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.FeedID,
+		arg.FolderID,
+	)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.FolderID,
+	)
+	return i, err
+}
+
+const deleteFeedFollow = `-- name: DeleteFeedFollow :execrows
 DELETE FROM feed_follows
 WHERE user_id = $1
     AND feed_id = $2
@@ -56,13 +110,116 @@ type DeleteFeedFollowParams struct {
 	FeedID uuid.UUID
 }
 
-func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) error {
-	_, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.UserID, arg.FeedID)
-	return err
+func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.UserID, arg.FeedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteFeedFollowByID = `-- name: DeleteFeedFollowByID :execrows
+DELETE FROM feed_follows
+WHERE id = $1
+    AND user_id = $2
+`
+
+type DeleteFeedFollowByIDParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeleteFeedFollowByID(ctx context.Context, arg DeleteFeedFollowByIDParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedFollowByID, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFeedFollowByID = `-- name: GetFeedFollowByID :one
+SELECT id, created_at, updated_at, user_id, feed_id, folder_id
+FROM feed_follows
+WHERE id = $1
+    AND user_id = $2
+`
+
+type GetFeedFollowByIDParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetFeedFollowByID(ctx context.Context, arg GetFeedFollowByIDParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedFollowByID, arg.ID, arg.UserID)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.FolderID,
+	)
+	return i, err
+}
+
+const getFeedFollowForFeed = `-- name: GetFeedFollowForFeed :one
+SELECT id, created_at, updated_at, user_id, feed_id, folder_id
+FROM feed_follows
+WHERE user_id = $1
+    AND feed_id = $2
+`
+
+type GetFeedFollowForFeedParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+}
+
+func (q *Queries) GetFeedFollowForFeed(ctx context.Context, arg GetFeedFollowForFeedParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedFollowForFeed, arg.UserID, arg.FeedID)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.FolderID,
+	)
+	return i, err
+}
+
+const getFeedFollowerIDs = `-- name: GetFeedFollowerIDs :many
+SELECT user_id
+FROM feed_follows
+WHERE feed_id = $1
+`
+
+func (q *Queries) GetFeedFollowerIDs(ctx context.Context, feedID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedFollowerIDs, feedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var user_id uuid.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getFeedFollows = `-- name: GetFeedFollows :many
-SELECT id, created_at, updated_at, user_id, feed_id
+SELECT id, created_at, updated_at, user_id, feed_id, folder_id
 FROM feed_follows
 WHERE user_id = $1
 `
@@ -82,6 +239,7 @@ func (q *Queries) GetFeedFollows(ctx context.Context, userID uuid.UUID) ([]FeedF
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
+			&i.FolderID,
 		); err != nil {
 			return nil, err
 		}
@@ -95,3 +253,86 @@ func (q *Queries) GetFeedFollows(ctx context.Context, userID uuid.UUID) ([]FeedF
 	}
 	return items, nil
 }
+
+const getFeedFollowsForExport = `-- name: GetFeedFollowsForExport :many
+SELECT feeds.name,
+    feeds.url,
+    feeds.site_url,
+    folders.name AS folder_name
+FROM feed_follows
+    JOIN feeds ON feeds.id = feed_follows.feed_id
+    LEFT JOIN folders ON folders.id = feed_follows.folder_id
+WHERE feed_follows.user_id = $1
+ORDER BY folders.name ASC NULLS FIRST,
+    feeds.name ASC
+`
+
+type GetFeedFollowsForExportRow struct {
+	Name       string
+	Url        string
+	SiteUrl    sql.NullString
+	FolderName sql.NullString
+}
+
+func (q *Queries) GetFeedFollowsForExport(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForExportRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedFollowsForExport, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedFollowsForExportRow
+	for rows.Next() {
+		var i GetFeedFollowsForExportRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.Url,
+			&i.SiteUrl,
+			&i.FolderName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setFeedFollowFolder = `-- name: SetFeedFollowFolder :one
+UPDATE feed_follows
+SET folder_id = $3,
+    updated_at = $4
+WHERE id = $1
+    AND user_id = $2
+RETURNING id, created_at, updated_at, user_id, feed_id, folder_id
+`
+
+type SetFeedFollowFolderParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	FolderID  uuid.NullUUID
+	UpdatedAt time.Time
+}
+
+func (q *Queries) SetFeedFollowFolder(ctx context.Context, arg SetFeedFollowFolderParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, setFeedFollowFolder,
+		arg.ID,
+		arg.UserID,
+		arg.FolderID,
+		arg.UpdatedAt,
+	)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.FolderID,
+	)
+	return i, err
+}
@@ -7,15 +7,24 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+INSERT INTO feeds (
+        id,
+        created_at,
+        updated_at,
+        name,
+        url,
+        user_id,
+        site_url
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (url) DO NOTHING
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at, site_url, orphaned_since
 `
 
 type CreateFeedParams struct {
@@ -25,6 +34,7 @@ type CreateFeedParams struct {
 	Name      string
 	Url       string
 	UserID    uuid.NullUUID
+	SiteUrl   sql.NullString
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -35,6 +45,7 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.SiteUrl,
 	)
 	var i Feed
 	err := row.Scan(
@@ -45,6 +56,9 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.NextFetchAt,
+		&i.SiteUrl,
+		&i.OrphanedSince,
 	)
 	return i, err
 }
@@ -59,8 +73,32 @@ func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteOrphanedFeeds = `-- name: DeleteOrphanedFeeds :execrows
+DELETE FROM feeds
+WHERE orphaned_since < $1::timestamp
+    AND NOT EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE feed_follows.feed_id = feeds.id
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts
+            JOIN post_stars ON post_stars.post_id = posts.id
+        WHERE posts.feed_id = feeds.id
+    )
+`
+
+func (q *Queries) DeleteOrphanedFeeds(ctx context.Context, orphanedBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOrphanedFeeds, orphanedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getFeedByID = `-- name: GetFeedByID :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at, site_url, orphaned_since
 FROM feeds
 WHERE id = $1
 LIMIT 1
@@ -77,55 +115,45 @@ func (q *Queries) GetFeedByID(ctx context.Context, id uuid.UUID) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.NextFetchAt,
+		&i.SiteUrl,
+		&i.OrphanedSince,
 	)
 	return i, err
 }
 
-const getFeeds = `-- name: GetFeeds :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+const getFeedByURL = `-- name: GetFeedByURL :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at, site_url, orphaned_since
 FROM feeds
+WHERE url = $1
+LIMIT 1
 `
 
-func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
-	rows, err := q.db.QueryContext(ctx, getFeeds)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Feed
-	for rows.Next() {
-		var i Feed
-		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Name,
-			&i.Url,
-			&i.UserID,
-			&i.LastFetchedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByURL, url)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.NextFetchAt,
+		&i.SiteUrl,
+		&i.OrphanedSince,
+	)
+	return i, err
 }
 
-const getNextFeedsToFetch = `-- name: GetNextFeedsToFetch :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+const getFeeds = `-- name: GetFeeds :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at, site_url, orphaned_since
 FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT $1
 `
 
-func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int32) ([]Feed, error) {
-	rows, err := q.db.QueryContext(ctx, getNextFeedsToFetch, limit)
+func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeeds)
 	if err != nil {
 		return nil, err
 	}
@@ -141,6 +169,9 @@ func (q *Queries) GetNextFeedsToFetch(ctx context.Context, limit int32) ([]Feed,
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.NextFetchAt,
+			&i.SiteUrl,
+			&i.OrphanedSince,
 		); err != nil {
 			return nil, err
 		}
@@ -160,7 +191,7 @@ UPDATE feeds
 SET last_fetched_at = Now(),
     updated_at = Now()
 WHERE id = $1
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at, site_url, orphaned_since
 `
 
 func (q *Queries) MarkFeedAsFetched(ctx context.Context, id uuid.UUID) (Feed, error) {
@@ -174,6 +205,125 @@ func (q *Queries) MarkFeedAsFetched(ctx context.Context, id uuid.UUID) (Feed, er
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.NextFetchAt,
+		&i.SiteUrl,
+		&i.OrphanedSince,
+	)
+	return i, err
+}
+
+const markOrphanedFeeds = `-- name: MarkOrphanedFeeds :execrows
+UPDATE feeds
+SET orphaned_since = $1::timestamp
+WHERE orphaned_since IS NULL
+    AND NOT EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE feed_follows.feed_id = feeds.id
+    )
+`
+
+func (q *Queries) MarkOrphanedFeeds(ctx context.Context, now time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markOrphanedFeeds, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setFeedNextFetchAt = `-- name: SetFeedNextFetchAt :exec
+UPDATE feeds
+SET next_fetch_at = $2,
+    updated_at = Now()
+WHERE id = $1
+`
+
+type SetFeedNextFetchAtParams struct {
+	ID          uuid.UUID
+	NextFetchAt sql.NullTime
+}
+
+func (q *Queries) SetFeedNextFetchAt(ctx context.Context, arg SetFeedNextFetchAtParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedNextFetchAt, arg.ID, arg.NextFetchAt)
+	return err
+}
+
+const setFeedSiteURL = `-- name: SetFeedSiteURL :exec
+UPDATE feeds
+SET site_url = $2,
+    updated_at = Now()
+WHERE id = $1
+    AND site_url IS DISTINCT FROM $2
+`
+
+type SetFeedSiteURLParams struct {
+	ID      uuid.UUID
+	SiteUrl sql.NullString
+}
+
+func (q *Queries) SetFeedSiteURL(ctx context.Context, arg SetFeedSiteURLParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedSiteURL, arg.ID, arg.SiteUrl)
+	return err
+}
+
+const unmarkFollowedFeeds = `-- name: UnmarkFollowedFeeds :execrows
+UPDATE feeds
+SET orphaned_since = NULL
+WHERE orphaned_since IS NOT NULL
+    AND EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE feed_follows.feed_id = feeds.id
+    )
+`
+
+func (q *Queries) UnmarkFollowedFeeds(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unmarkFollowedFeeds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateFeed = `-- name: UpdateFeed :one
+UPDATE feeds
+SET name = $1,
+    next_fetch_at = CASE
+        WHEN url = $2 THEN next_fetch_at
+        ELSE NULL
+    END,
+    url = $2,
+    updated_at = $3
+WHERE id = $4
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at, site_url, orphaned_since
+`
+
+type UpdateFeedParams struct {
+	Name      string
+	Url       string
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (q *Queries) UpdateFeed(ctx context.Context, arg UpdateFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, updateFeed,
+		arg.Name,
+		arg.Url,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.NextFetchAt,
+		&i.SiteUrl,
+		&i.OrphanedSince,
 	)
 	return i, err
 }
@@ -11,6 +11,22 @@ import (
 	"github.com/google/uuid"
 )
 
+type DigestSetting struct {
+	UserID           uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Email            string
+	Frequency        string
+	MinuteOfDay      int32
+	Weekday          sql.NullInt32
+	Timezone         string
+	FolderIds        []uuid.UUID
+	NextSendAt       time.Time
+	LastSentAt       sql.NullTime
+	EmailConfirmedAt sql.NullTime
+	ConfirmToken     sql.NullString
+}
+
 type Feed struct {
 	ID            uuid.UUID
 	CreatedAt     time.Time
@@ -19,6 +35,21 @@ type Feed struct {
 	Url           string
 	UserID        uuid.NullUUID
 	LastFetchedAt sql.NullTime
+	NextFetchAt   sql.NullTime
+	SiteUrl       sql.NullString
+	OrphanedSince sql.NullTime
+}
+
+type FeedFetch struct {
+	ID            uuid.UUID
+	FeedID        uuid.UUID
+	FetchedAt     time.Time
+	StatusCode    sql.NullInt32
+	Bytes         int64
+	DurationMs    int32
+	ItemsSeen     int32
+	ItemsInserted int32
+	Error         sql.NullString
 }
 
 type FeedFollow struct {
@@ -27,18 +58,77 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	FolderID  uuid.NullUUID
+}
+
+type FeedRetention struct {
+	FeedID     uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	MaxAgeDays sql.NullInt32
+	MaxPosts   sql.NullInt32
+}
+
+type FilterRule struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	FeedID    uuid.NullUUID
+	Field     string
+	MatchType string
+	Pattern   string
+}
+
+type Folder struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
 }
 
 type Post struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Name        string
-	Title       string
-	Description sql.NullString
-	PublishedAt time.Time
-	Url         string
-	FeedID      uuid.UUID
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Name         string
+	Title        string
+	Description  sql.NullString
+	PublishedAt  time.Time
+	Url          string
+	FeedID       uuid.UUID
+	CanonicalUrl string
+	Content      sql.NullString
+	Search       interface{}
+	Seq          int64
+	Author       sql.NullString
+	Categories   []string
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PostStar struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type ScrapeJob struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	FeedID         uuid.UUID
+	Status         string
+	Attempts       int32
+	RunAt          time.Time
+	LeaseExpiresAt sql.NullTime
+	LastError      sql.NullString
+	LastStatusCode sql.NullInt32
 }
 
 type User struct {
@@ -47,4 +137,32 @@ type User struct {
 	UpdatedAt time.Time
 	Name      string
 	ApiKey    string
+	IsAdmin   bool
+	FeedToken sql.NullString
+}
+
+type Webhook struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Url       string
+	Secret    string
+	FeedIds   []uuid.UUID
+	Keywords  []string
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	WebhookID      uuid.UUID
+	PostID         uuid.UUID
+	Status         string
+	Attempts       int32
+	RunAt          time.Time
+	LeaseExpiresAt sql.NullTime
+	LastStatusCode sql.NullInt32
+	LastError      sql.NullString
+	DeliveredAt    sql.NullTime
 }
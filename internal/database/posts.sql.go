@@ -11,57 +11,191 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const createPost = `-- name: CreatePost :one
-INSERT INTO posts (
-        id,
-        created_at,
-        updated_at,
-        name,
-        -- ✅ Ensure "name" is included here
-        title,
-        description,
-        published_at,
-        url,
-        feed_id
-    )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-RETURNING id,
-    created_at,
-    updated_at,
-    name,
-    title,
-    description,
-    published_at,
-    url,
-    feed_id
+const createPosts = `-- name: CreatePosts :many
+WITH inserted AS (
+    INSERT INTO posts (
+            id,
+            created_at,
+            updated_at,
+            name,
+            title,
+            description,
+            published_at,
+            url,
+            feed_id,
+            canonical_url,
+            content,
+            author,
+            categories
+        )
+    SELECT item.id,
+        $1::timestamp,
+        $1::timestamp,
+        $2::text,
+        item.title,
+        NULLIF(item.description, ''),
+        item.published_at,
+        item.url,
+        $3::uuid,
+        item.canonical_url,
+        NULLIF(item.content, ''),
+        NULLIF(item.author, ''),
+        string_to_array(item.categories, chr(31))
+    FROM unnest(
+            $4::uuid [],
+            $5::text [],
+            $6::text [],
+            $7::timestamp [],
+            $8::text [],
+            $9::text [],
+            $10::text [],
+            $11::text [],
+            $12::text []
+        ) AS item(
+            id,
+            title,
+            description,
+            published_at,
+            url,
+            canonical_url,
+            content,
+            author,
+            categories
+        ) ON CONFLICT (feed_id, canonical_url) DO NOTHING
+    RETURNING id, created_at, updated_at, name, title, description, published_at, url, feed_id, canonical_url, content, search, seq, author, categories
+),
+queued AS (
+    INSERT INTO webhook_deliveries (
+            id,
+            created_at,
+            updated_at,
+            webhook_id,
+            post_id,
+            status,
+            attempts,
+            run_at
+        )
+    SELECT gen_random_uuid(),
+        $1::timestamp,
+        $1::timestamp,
+        webhooks.id,
+        inserted.id,
+        'pending',
+        0,
+        $1::timestamp
+    FROM inserted
+        JOIN feed_follows ON feed_follows.feed_id = inserted.feed_id
+        JOIN webhooks ON webhooks.user_id = feed_follows.user_id
+    WHERE (
+            cardinality(webhooks.feed_ids) = 0
+            OR inserted.feed_id = ANY(webhooks.feed_ids)
+        )
+        AND (
+            cardinality(webhooks.keywords) = 0
+            OR EXISTS (
+                SELECT 1
+                FROM unnest(webhooks.keywords) AS keyword
+                WHERE strpos(
+                        lower(concat_ws(' ', inserted.title, inserted.description)),
+                        lower(keyword)
+                    ) > 0
+            )
+        ) ON CONFLICT (webhook_id, post_id) DO NOTHING
+)
+SELECT id, created_at, updated_at, name, title, description, published_at, url, feed_id, canonical_url, content, search, seq, author, categories
+FROM inserted
 `
 
-type CreatePostParams struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Name        string
-	Title       string
-	Description sql.NullString
-	PublishedAt time.Time
-	Url         string
-	FeedID      uuid.UUID
-}
-
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.db.QueryRowContext(ctx, createPost,
-		arg.ID,
-		arg.CreatedAt,
-		arg.UpdatedAt,
+type CreatePostsParams struct {
+	Now           time.Time
+	Name          string
+	FeedID        uuid.UUID
+	Ids           []uuid.UUID
+	Titles        []string
+	Descriptions  []string
+	PublishedAts  []time.Time
+	Urls          []string
+	CanonicalUrls []string
+	Contents      []string
+	Authors       []string
+	Categories    []string
+}
+
+func (q *Queries) CreatePosts(ctx context.Context, arg CreatePostsParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, createPosts,
+		arg.Now,
 		arg.Name,
-		arg.Title,
-		arg.Description,
-		arg.PublishedAt,
-		arg.Url,
 		arg.FeedID,
+		pq.Array(arg.Ids),
+		pq.Array(arg.Titles),
+		pq.Array(arg.Descriptions),
+		pq.Array(arg.PublishedAts),
+		pq.Array(arg.Urls),
+		pq.Array(arg.CanonicalUrls),
+		pq.Array(arg.Contents),
+		pq.Array(arg.Authors),
+		pq.Array(arg.Categories),
 	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Title,
+			&i.Description,
+			&i.PublishedAt,
+			&i.Url,
+			&i.FeedID,
+			&i.CanonicalUrl,
+			&i.Content,
+			&i.Search,
+			&i.Seq,
+			&i.Author,
+			pq.Array(&i.Categories),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMaxPostSeq = `-- name: GetMaxPostSeq :one
+SELECT COALESCE(MAX(seq), 0)::bigint AS max_seq
+FROM posts
+`
+
+func (q *Queries) GetMaxPostSeq(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getMaxPostSeq)
+	var max_seq int64
+	err := row.Scan(&max_seq)
+	return max_seq, err
+}
+
+const getPostByID = `-- name: GetPostByID :one
+SELECT id, created_at, updated_at, name, title, description, published_at, url, feed_id, canonical_url, content, search, seq, author, categories
+FROM posts
+WHERE id = $1
+`
+
+func (q *Queries) GetPostByID(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByID, id)
 	var i Post
 	err := row.Scan(
 		&i.ID,
@@ -73,26 +207,329 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.PublishedAt,
 		&i.Url,
 		&i.FeedID,
+		&i.CanonicalUrl,
+		&i.Content,
+		&i.Search,
+		&i.Seq,
+		&i.Author,
+		pq.Array(&i.Categories),
 	)
 	return i, err
 }
 
+const getPostDuplicates = `-- name: GetPostDuplicates :many
+SELECT duplicates.id, duplicates.created_at, duplicates.updated_at, duplicates.name, duplicates.title, duplicates.description, duplicates.published_at, duplicates.url, duplicates.feed_id, duplicates.canonical_url, duplicates.content, duplicates.search, duplicates.seq, duplicates.author, duplicates.categories,
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.user_id = $1
+            AND post_reads.post_id = duplicates.id
+    ) AS read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.user_id = $1
+            AND post_stars.post_id = duplicates.id
+    ) AS starred
+FROM posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+    JOIN posts AS duplicates ON duplicates.canonical_url = posts.canonical_url
+WHERE posts.id = $2
+    AND feed_follows.user_id = $1
+ORDER BY duplicates.created_at ASC,
+    duplicates.id ASC
+`
+
+type GetPostDuplicatesParams struct {
+	UserID uuid.UUID
+	ID     uuid.UUID
+}
+
+type GetPostDuplicatesRow struct {
+	Post    Post
+	Read    bool
+	Starred bool
+}
+
+func (q *Queries) GetPostDuplicates(ctx context.Context, arg GetPostDuplicatesParams) ([]GetPostDuplicatesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostDuplicates, arg.UserID, arg.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostDuplicatesRow
+	for rows.Next() {
+		var i GetPostDuplicatesRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.CreatedAt,
+			&i.Post.UpdatedAt,
+			&i.Post.Name,
+			&i.Post.Title,
+			&i.Post.Description,
+			&i.Post.PublishedAt,
+			&i.Post.Url,
+			&i.Post.FeedID,
+			&i.Post.CanonicalUrl,
+			&i.Post.Content,
+			&i.Post.Search,
+			&i.Post.Seq,
+			&i.Post.Author,
+			pq.Array(&i.Post.Categories),
+			&i.Read,
+			&i.Starred,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForFeedInSeqRange = `-- name: GetPostsForFeedInSeqRange :many
+SELECT id, created_at, updated_at, name, title, description, published_at, url, feed_id, canonical_url, content, search, seq, author, categories
+FROM posts
+WHERE feed_id = $1
+    AND seq BETWEEN $2 AND $3
+ORDER BY seq ASC
+`
+
+type GetPostsForFeedInSeqRangeParams struct {
+	FeedID uuid.UUID
+	MinSeq int64
+	MaxSeq int64
+}
+
+func (q *Queries) GetPostsForFeedInSeqRange(ctx context.Context, arg GetPostsForFeedInSeqRangeParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForFeedInSeqRange, arg.FeedID, arg.MinSeq, arg.MaxSeq)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Title,
+			&i.Description,
+			&i.PublishedAt,
+			&i.Url,
+			&i.FeedID,
+			&i.CanonicalUrl,
+			&i.Content,
+			&i.Search,
+			&i.Seq,
+			&i.Author,
+			pq.Array(&i.Categories),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.name, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id
+SELECT posts.id, posts.created_at, posts.updated_at, posts.name, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id, posts.canonical_url, posts.content, posts.search, posts.seq, posts.author, posts.categories,
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id
+            AND post_reads.post_id = posts.id
+    ) AS read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.user_id = feed_follows.user_id
+            AND post_stars.post_id = posts.id
+    ) AS starred,
+    post_hidden_for_user(feed_follows.user_id, posts) AS hidden
 FROM posts
     JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
 WHERE feed_follows.user_id = $1
-ORDER BY posts.created_at DESC
-LIMIT $2
+    AND (
+        COALESCE(cardinality($2::uuid []), 0) = 0
+        OR posts.feed_id = ANY($2::uuid [])
+    )
+    AND (
+        $3::uuid IS NULL
+        OR feed_follows.folder_id = $3::uuid
+    )
+    AND (
+        $4::text IS NULL
+        OR posts.search @@ to_tsquery('english', $4::text)
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts AS earlier
+            JOIN feed_follows AS earlier_follows ON earlier_follows.feed_id = earlier.feed_id
+        WHERE earlier_follows.user_id = $1
+            AND earlier.canonical_url = posts.canonical_url
+            AND (earlier.created_at, earlier.id) < (posts.created_at, posts.id)
+            AND (
+                COALESCE(cardinality($2::uuid []), 0) = 0
+                OR earlier.feed_id = ANY($2::uuid [])
+            )
+            AND (
+                $3::uuid IS NULL
+                OR earlier_follows.folder_id = $3::uuid
+            )
+            AND (
+                $4::text IS NULL
+                OR earlier.search @@ to_tsquery('english', $4::text)
+            )
+            AND (
+                $5::bool
+                OR NOT post_hidden_for_user(earlier_follows.user_id, earlier)
+            )
+            AND (
+                $6::timestamp IS NULL
+                OR earlier.published_at >= $6::timestamp
+            )
+            AND (
+                $7::timestamp IS NULL
+                OR earlier.published_at < $7::timestamp
+            )
+    )
+    AND (
+        NOT $8::bool
+        OR NOT EXISTS (
+            SELECT 1
+            FROM post_reads
+            WHERE post_reads.user_id = feed_follows.user_id
+                AND post_reads.post_id = posts.id
+        )
+    )
+    AND (
+        $5::bool
+        OR NOT post_hidden_for_user(feed_follows.user_id, posts)
+    )
+    AND (
+        $6::timestamp IS NULL
+        OR posts.published_at >= $6::timestamp
+    )
+    AND (
+        $7::timestamp IS NULL
+        OR posts.published_at < $7::timestamp
+    )
+    AND (
+        $9::timestamp IS NULL
+        OR (posts.published_at, posts.id) < ($9::timestamp, $10::uuid)
+    )
+ORDER BY posts.published_at DESC,
+    posts.id DESC
+LIMIT $11
 `
 
 type GetPostsForUserParams struct {
+	UserID      uuid.UUID
+	FeedIds     []uuid.UUID
+	FolderID    uuid.NullUUID
+	SearchQuery sql.NullString
+	ShowHidden  bool
+	Since       sql.NullTime
+	Until       sql.NullTime
+	UnreadOnly  bool
+	CursorAt    sql.NullTime
+	CursorID    uuid.UUID
+	PageSize    int32
+}
+
+type GetPostsForUserRow struct {
+	Post    Post
+	Read    bool
+	Starred bool
+	Hidden  bool
+}
+
+func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		pq.Array(arg.FeedIds),
+		arg.FolderID,
+		arg.SearchQuery,
+		arg.ShowHidden,
+		arg.Since,
+		arg.Until,
+		arg.UnreadOnly,
+		arg.CursorAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForUserRow
+	for rows.Next() {
+		var i GetPostsForUserRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.CreatedAt,
+			&i.Post.UpdatedAt,
+			&i.Post.Name,
+			&i.Post.Title,
+			&i.Post.Description,
+			&i.Post.PublishedAt,
+			&i.Post.Url,
+			&i.Post.FeedID,
+			&i.Post.CanonicalUrl,
+			&i.Post.Content,
+			&i.Post.Search,
+			&i.Post.Seq,
+			&i.Post.Author,
+			pq.Array(&i.Post.Categories),
+			&i.Read,
+			&i.Starred,
+			&i.Hidden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForUserAfterSeq = `-- name: GetPostsForUserAfterSeq :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.name, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id, posts.canonical_url, posts.content, posts.search, posts.seq, posts.author, posts.categories
+FROM posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1
+    AND posts.seq > $2
+ORDER BY posts.seq ASC
+LIMIT $3
+`
+
+type GetPostsForUserAfterSeqParams struct {
 	UserID uuid.UUID
+	Seq    int64
 	Limit  int32
 }
 
-func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+func (q *Queries) GetPostsForUserAfterSeq(ctx context.Context, arg GetPostsForUserAfterSeqParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUserAfterSeq, arg.UserID, arg.Seq, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -110,6 +547,640 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.PublishedAt,
 			&i.Url,
 			&i.FeedID,
+			&i.CanonicalUrl,
+			&i.Content,
+			&i.Search,
+			&i.Seq,
+			&i.Author,
+			pq.Array(&i.Categories),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForUserByIngested = `-- name: GetPostsForUserByIngested :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.name, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id, posts.canonical_url, posts.content, posts.search, posts.seq, posts.author, posts.categories,
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id
+            AND post_reads.post_id = posts.id
+    ) AS read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.user_id = feed_follows.user_id
+            AND post_stars.post_id = posts.id
+    ) AS starred,
+    post_hidden_for_user(feed_follows.user_id, posts) AS hidden
+FROM posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1
+    AND (
+        COALESCE(cardinality($2::uuid []), 0) = 0
+        OR posts.feed_id = ANY($2::uuid [])
+    )
+    AND (
+        $3::uuid IS NULL
+        OR feed_follows.folder_id = $3::uuid
+    )
+    AND (
+        $4::text IS NULL
+        OR posts.search @@ to_tsquery('english', $4::text)
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts AS earlier
+            JOIN feed_follows AS earlier_follows ON earlier_follows.feed_id = earlier.feed_id
+        WHERE earlier_follows.user_id = $1
+            AND earlier.canonical_url = posts.canonical_url
+            AND (earlier.created_at, earlier.id) < (posts.created_at, posts.id)
+            AND (
+                COALESCE(cardinality($2::uuid []), 0) = 0
+                OR earlier.feed_id = ANY($2::uuid [])
+            )
+            AND (
+                $3::uuid IS NULL
+                OR earlier_follows.folder_id = $3::uuid
+            )
+            AND (
+                $4::text IS NULL
+                OR earlier.search @@ to_tsquery('english', $4::text)
+            )
+            AND (
+                $5::bool
+                OR NOT post_hidden_for_user(earlier_follows.user_id, earlier)
+            )
+            AND (
+                $6::timestamp IS NULL
+                OR earlier.created_at >= $6::timestamp
+            )
+            AND (
+                $7::timestamp IS NULL
+                OR earlier.created_at < $7::timestamp
+            )
+    )
+    AND (
+        NOT $8::bool
+        OR NOT EXISTS (
+            SELECT 1
+            FROM post_reads
+            WHERE post_reads.user_id = feed_follows.user_id
+                AND post_reads.post_id = posts.id
+        )
+    )
+    AND (
+        $5::bool
+        OR NOT post_hidden_for_user(feed_follows.user_id, posts)
+    )
+    AND (
+        $6::timestamp IS NULL
+        OR posts.created_at >= $6::timestamp
+    )
+    AND (
+        $7::timestamp IS NULL
+        OR posts.created_at < $7::timestamp
+    )
+    AND (
+        $9::timestamp IS NULL
+        OR (posts.created_at, posts.id) < ($9::timestamp, $10::uuid)
+    )
+ORDER BY posts.created_at DESC,
+    posts.id DESC
+LIMIT $11
+`
+
+type GetPostsForUserByIngestedParams struct {
+	UserID      uuid.UUID
+	FeedIds     []uuid.UUID
+	FolderID    uuid.NullUUID
+	SearchQuery sql.NullString
+	ShowHidden  bool
+	Since       sql.NullTime
+	Until       sql.NullTime
+	UnreadOnly  bool
+	CursorAt    sql.NullTime
+	CursorID    uuid.UUID
+	PageSize    int32
+}
+
+type GetPostsForUserByIngestedRow struct {
+	Post    Post
+	Read    bool
+	Starred bool
+	Hidden  bool
+}
+
+func (q *Queries) GetPostsForUserByIngested(ctx context.Context, arg GetPostsForUserByIngestedParams) ([]GetPostsForUserByIngestedRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUserByIngested,
+		arg.UserID,
+		pq.Array(arg.FeedIds),
+		arg.FolderID,
+		arg.SearchQuery,
+		arg.ShowHidden,
+		arg.Since,
+		arg.Until,
+		arg.UnreadOnly,
+		arg.CursorAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForUserByIngestedRow
+	for rows.Next() {
+		var i GetPostsForUserByIngestedRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.CreatedAt,
+			&i.Post.UpdatedAt,
+			&i.Post.Name,
+			&i.Post.Title,
+			&i.Post.Description,
+			&i.Post.PublishedAt,
+			&i.Post.Url,
+			&i.Post.FeedID,
+			&i.Post.CanonicalUrl,
+			&i.Post.Content,
+			&i.Post.Search,
+			&i.Post.Seq,
+			&i.Post.Author,
+			pq.Array(&i.Post.Categories),
+			&i.Read,
+			&i.Starred,
+			&i.Hidden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForUserByIngestedOldestFirst = `-- name: GetPostsForUserByIngestedOldestFirst :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.name, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id, posts.canonical_url, posts.content, posts.search, posts.seq, posts.author, posts.categories,
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id
+            AND post_reads.post_id = posts.id
+    ) AS read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.user_id = feed_follows.user_id
+            AND post_stars.post_id = posts.id
+    ) AS starred,
+    post_hidden_for_user(feed_follows.user_id, posts) AS hidden
+FROM posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1
+    AND (
+        COALESCE(cardinality($2::uuid []), 0) = 0
+        OR posts.feed_id = ANY($2::uuid [])
+    )
+    AND (
+        $3::uuid IS NULL
+        OR feed_follows.folder_id = $3::uuid
+    )
+    AND (
+        $4::text IS NULL
+        OR posts.search @@ to_tsquery('english', $4::text)
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts AS earlier
+            JOIN feed_follows AS earlier_follows ON earlier_follows.feed_id = earlier.feed_id
+        WHERE earlier_follows.user_id = $1
+            AND earlier.canonical_url = posts.canonical_url
+            AND (earlier.created_at, earlier.id) < (posts.created_at, posts.id)
+            AND (
+                COALESCE(cardinality($2::uuid []), 0) = 0
+                OR earlier.feed_id = ANY($2::uuid [])
+            )
+            AND (
+                $3::uuid IS NULL
+                OR earlier_follows.folder_id = $3::uuid
+            )
+            AND (
+                $4::text IS NULL
+                OR earlier.search @@ to_tsquery('english', $4::text)
+            )
+            AND (
+                $5::bool
+                OR NOT post_hidden_for_user(earlier_follows.user_id, earlier)
+            )
+            AND (
+                $6::timestamp IS NULL
+                OR earlier.created_at >= $6::timestamp
+            )
+            AND (
+                $7::timestamp IS NULL
+                OR earlier.created_at < $7::timestamp
+            )
+    )
+    AND (
+        NOT $8::bool
+        OR NOT EXISTS (
+            SELECT 1
+            FROM post_reads
+            WHERE post_reads.user_id = feed_follows.user_id
+                AND post_reads.post_id = posts.id
+        )
+    )
+    AND (
+        $5::bool
+        OR NOT post_hidden_for_user(feed_follows.user_id, posts)
+    )
+    AND (
+        $6::timestamp IS NULL
+        OR posts.created_at >= $6::timestamp
+    )
+    AND (
+        $7::timestamp IS NULL
+        OR posts.created_at < $7::timestamp
+    )
+    AND (
+        $9::timestamp IS NULL
+        OR (posts.created_at, posts.id) > ($9::timestamp, $10::uuid)
+    )
+ORDER BY posts.created_at ASC,
+    posts.id ASC
+LIMIT $11
+`
+
+type GetPostsForUserByIngestedOldestFirstParams struct {
+	UserID      uuid.UUID
+	FeedIds     []uuid.UUID
+	FolderID    uuid.NullUUID
+	SearchQuery sql.NullString
+	ShowHidden  bool
+	Since       sql.NullTime
+	Until       sql.NullTime
+	UnreadOnly  bool
+	CursorAt    sql.NullTime
+	CursorID    uuid.UUID
+	PageSize    int32
+}
+
+type GetPostsForUserByIngestedOldestFirstRow struct {
+	Post    Post
+	Read    bool
+	Starred bool
+	Hidden  bool
+}
+
+func (q *Queries) GetPostsForUserByIngestedOldestFirst(ctx context.Context, arg GetPostsForUserByIngestedOldestFirstParams) ([]GetPostsForUserByIngestedOldestFirstRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUserByIngestedOldestFirst,
+		arg.UserID,
+		pq.Array(arg.FeedIds),
+		arg.FolderID,
+		arg.SearchQuery,
+		arg.ShowHidden,
+		arg.Since,
+		arg.Until,
+		arg.UnreadOnly,
+		arg.CursorAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForUserByIngestedOldestFirstRow
+	for rows.Next() {
+		var i GetPostsForUserByIngestedOldestFirstRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.CreatedAt,
+			&i.Post.UpdatedAt,
+			&i.Post.Name,
+			&i.Post.Title,
+			&i.Post.Description,
+			&i.Post.PublishedAt,
+			&i.Post.Url,
+			&i.Post.FeedID,
+			&i.Post.CanonicalUrl,
+			&i.Post.Content,
+			&i.Post.Search,
+			&i.Post.Seq,
+			&i.Post.Author,
+			pq.Array(&i.Post.Categories),
+			&i.Read,
+			&i.Starred,
+			&i.Hidden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForUserOldestFirst = `-- name: GetPostsForUserOldestFirst :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.name, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id, posts.canonical_url, posts.content, posts.search, posts.seq, posts.author, posts.categories,
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id
+            AND post_reads.post_id = posts.id
+    ) AS read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.user_id = feed_follows.user_id
+            AND post_stars.post_id = posts.id
+    ) AS starred,
+    post_hidden_for_user(feed_follows.user_id, posts) AS hidden
+FROM posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1
+    AND (
+        COALESCE(cardinality($2::uuid []), 0) = 0
+        OR posts.feed_id = ANY($2::uuid [])
+    )
+    AND (
+        $3::uuid IS NULL
+        OR feed_follows.folder_id = $3::uuid
+    )
+    AND (
+        $4::text IS NULL
+        OR posts.search @@ to_tsquery('english', $4::text)
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts AS earlier
+            JOIN feed_follows AS earlier_follows ON earlier_follows.feed_id = earlier.feed_id
+        WHERE earlier_follows.user_id = $1
+            AND earlier.canonical_url = posts.canonical_url
+            AND (earlier.created_at, earlier.id) < (posts.created_at, posts.id)
+            AND (
+                COALESCE(cardinality($2::uuid []), 0) = 0
+                OR earlier.feed_id = ANY($2::uuid [])
+            )
+            AND (
+                $3::uuid IS NULL
+                OR earlier_follows.folder_id = $3::uuid
+            )
+            AND (
+                $4::text IS NULL
+                OR earlier.search @@ to_tsquery('english', $4::text)
+            )
+            AND (
+                $5::bool
+                OR NOT post_hidden_for_user(earlier_follows.user_id, earlier)
+            )
+            AND (
+                $6::timestamp IS NULL
+                OR earlier.published_at >= $6::timestamp
+            )
+            AND (
+                $7::timestamp IS NULL
+                OR earlier.published_at < $7::timestamp
+            )
+    )
+    AND (
+        NOT $8::bool
+        OR NOT EXISTS (
+            SELECT 1
+            FROM post_reads
+            WHERE post_reads.user_id = feed_follows.user_id
+                AND post_reads.post_id = posts.id
+        )
+    )
+    AND (
+        $5::bool
+        OR NOT post_hidden_for_user(feed_follows.user_id, posts)
+    )
+    AND (
+        $6::timestamp IS NULL
+        OR posts.published_at >= $6::timestamp
+    )
+    AND (
+        $7::timestamp IS NULL
+        OR posts.published_at < $7::timestamp
+    )
+    AND (
+        $9::timestamp IS NULL
+        OR (posts.published_at, posts.id) > ($9::timestamp, $10::uuid)
+    )
+ORDER BY posts.published_at ASC,
+    posts.id ASC
+LIMIT $11
+`
+
+type GetPostsForUserOldestFirstParams struct {
+	UserID      uuid.UUID
+	FeedIds     []uuid.UUID
+	FolderID    uuid.NullUUID
+	SearchQuery sql.NullString
+	ShowHidden  bool
+	Since       sql.NullTime
+	Until       sql.NullTime
+	UnreadOnly  bool
+	CursorAt    sql.NullTime
+	CursorID    uuid.UUID
+	PageSize    int32
+}
+
+type GetPostsForUserOldestFirstRow struct {
+	Post    Post
+	Read    bool
+	Starred bool
+	Hidden  bool
+}
+
+func (q *Queries) GetPostsForUserOldestFirst(ctx context.Context, arg GetPostsForUserOldestFirstParams) ([]GetPostsForUserOldestFirstRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUserOldestFirst,
+		arg.UserID,
+		pq.Array(arg.FeedIds),
+		arg.FolderID,
+		arg.SearchQuery,
+		arg.ShowHidden,
+		arg.Since,
+		arg.Until,
+		arg.UnreadOnly,
+		arg.CursorAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForUserOldestFirstRow
+	for rows.Next() {
+		var i GetPostsForUserOldestFirstRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.CreatedAt,
+			&i.Post.UpdatedAt,
+			&i.Post.Name,
+			&i.Post.Title,
+			&i.Post.Description,
+			&i.Post.PublishedAt,
+			&i.Post.Url,
+			&i.Post.FeedID,
+			&i.Post.CanonicalUrl,
+			&i.Post.Content,
+			&i.Post.Search,
+			&i.Post.Seq,
+			&i.Post.Author,
+			pq.Array(&i.Post.Categories),
+			&i.Read,
+			&i.Starred,
+			&i.Hidden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockPostSeq = `-- name: LockPostSeq :exec
+SELECT pg_advisory_xact_lock('posts'::regclass::oid::bigint)
+`
+
+func (q *Queries) LockPostSeq(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, lockPostSeq)
+	return err
+}
+
+const notifyNewPosts = `-- name: NotifyNewPosts :exec
+SELECT pg_notify('new_posts', $1::text)
+`
+
+func (q *Queries) NotifyNewPosts(ctx context.Context, payload string) error {
+	_, err := q.db.ExecContext(ctx, notifyNewPosts, payload)
+	return err
+}
+
+const searchPostsForUser = `-- name: SearchPostsForUser :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.name, posts.title, posts.description, posts.published_at, posts.url, posts.feed_id, posts.canonical_url, posts.content, posts.search, posts.seq, posts.author, posts.categories,
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id
+            AND post_reads.post_id = posts.id
+    ) AS read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.user_id = feed_follows.user_id
+            AND post_stars.post_id = posts.id
+    ) AS starred,
+    ts_rank_cd(posts.search, query)::real AS rank,
+    ts_headline(
+        'english',
+        regexp_replace(
+            concat_ws(' ', posts.title, posts.description, posts.content),
+            '<[^>]*>',
+            ' ',
+            'g'
+        ),
+        query,
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10'
+    )::text AS snippet
+FROM posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+    CROSS JOIN to_tsquery('english', $1::text) AS query
+WHERE feed_follows.user_id = $2
+    AND posts.search @@ query
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts AS earlier
+            JOIN feed_follows AS earlier_follows ON earlier_follows.feed_id = earlier.feed_id
+        WHERE earlier_follows.user_id = $2
+            AND earlier.canonical_url = posts.canonical_url
+            AND (earlier.created_at, earlier.id) < (posts.created_at, posts.id)
+            AND earlier.search @@ query
+    )
+ORDER BY rank DESC,
+    posts.published_at DESC,
+    posts.id DESC
+LIMIT $3 OFFSET $4
+`
+
+type SearchPostsForUserParams struct {
+	Query      string
+	UserID     uuid.UUID
+	PageSize   int32
+	PageOffset int32
+}
+
+type SearchPostsForUserRow struct {
+	Post    Post
+	Read    bool
+	Starred bool
+	Rank    float32
+	Snippet string
+}
+
+func (q *Queries) SearchPostsForUser(ctx context.Context, arg SearchPostsForUserParams) ([]SearchPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPostsForUser,
+		arg.Query,
+		arg.UserID,
+		arg.PageSize,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsForUserRow
+	for rows.Next() {
+		var i SearchPostsForUserRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.CreatedAt,
+			&i.Post.UpdatedAt,
+			&i.Post.Name,
+			&i.Post.Title,
+			&i.Post.Description,
+			&i.Post.PublishedAt,
+			&i.Post.Url,
+			&i.Post.FeedID,
+			&i.Post.CanonicalUrl,
+			&i.Post.Content,
+			&i.Post.Search,
+			&i.Post.Seq,
+			&i.Post.Author,
+			pq.Array(&i.Post.Categories),
+			&i.Read,
+			&i.Starred,
+			&i.Rank,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
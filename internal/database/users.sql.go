@@ -12,6 +12,23 @@ import (
 	"github.com/google/uuid"
 )
 
+const clearUserFeedToken = `-- name: ClearUserFeedToken :exec
+UPDATE users
+SET feed_token = NULL,
+    updated_at = $2
+WHERE id = $1
+`
+
+type ClearUserFeedTokenParams struct {
+	ID        uuid.UUID
+	UpdatedAt time.Time
+}
+
+func (q *Queries) ClearUserFeedToken(ctx context.Context, arg ClearUserFeedTokenParams) error {
+	_, err := q.db.ExecContext(ctx, clearUserFeedToken, arg.ID, arg.UpdatedAt)
+	return err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, created_at, updated_at, name, api_key)
 VALUES (
@@ -21,7 +38,7 @@ VALUES (
         $4,
         encode(digest(random()::text, 'sha256'), 'hex')
     )
-RETURNING id, created_at, updated_at, name, api_key
+RETURNING id, created_at, updated_at, name, api_key, is_admin, feed_token
 `
 
 type CreateUserParams struct {
@@ -45,12 +62,14 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.Name,
 		&i.ApiKey,
+		&i.IsAdmin,
+		&i.FeedToken,
 	)
 	return i, err
 }
 
 const getUserByAPIKey = `-- name: GetUserByAPIKey :one
-SELECT id, created_at, updated_at, name, api_key
+SELECT id, created_at, updated_at, name, api_key, is_admin, feed_token
 FROM users
 WHERE api_key = $1
 `
@@ -64,6 +83,57 @@ func (q *Queries) GetUserByAPIKey(ctx context.Context, apiKey string) (User, err
 		&i.UpdatedAt,
 		&i.Name,
 		&i.ApiKey,
+		&i.IsAdmin,
+		&i.FeedToken,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, name, api_key, is_admin, feed_token
+FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKey,
+		&i.IsAdmin,
+		&i.FeedToken,
+	)
+	return i, err
+}
+
+const rotateUserFeedToken = `-- name: RotateUserFeedToken :one
+UPDATE users
+SET feed_token = encode(gen_random_bytes(32), 'hex'),
+    updated_at = $2
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, api_key, is_admin, feed_token
+`
+
+type RotateUserFeedTokenParams struct {
+	ID        uuid.UUID
+	UpdatedAt time.Time
+}
+
+func (q *Queries) RotateUserFeedToken(ctx context.Context, arg RotateUserFeedTokenParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, rotateUserFeedToken, arg.ID, arg.UpdatedAt)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.ApiKey,
+		&i.IsAdmin,
+		&i.FeedToken,
 	)
 	return i, err
 }
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"time"
+)
+
+// mailer sends email through an SMTP server.
+type mailer struct {
+	Addr     string // host:port of the SMTP server
+	Username string // Empty to send without logging in
+	Password string
+	From     string // Address the mail comes from
+}
+
+// mailMessage is an email with a plain text and an HTML version of the same content.
+type mailMessage struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// smtpTimeout bounds a whole conversation with the SMTP server, from dialing to QUIT.
+const smtpTimeout = 30 * time.Second
+
+// send delivers msg, switching to STARTTLS whenever the server offers it.
+// It gives up once ctx is done or smtpTimeout has passed, whichever comes first.
+func (m *mailer) send(ctx context.Context, msg mailMessage, now time.Time) error {
+	body, err := buildMail(m.From, msg, now)
+	if err != nil {
+		return err
+	}
+	host, _, err := net.SplitHostPort(m.Addr)
+	if err != nil {
+		return fmt.Errorf("parsing SMTP address: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", m.Addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	// The deadline covers a server that goes quiet, closing the connection covers ctx being cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return err
+		}
+	}
+	if m.Username != "" {
+		err = client.Auth(smtp.PlainAuth("", m.Username, m.Password, host))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Mail(m.From)
+	if err != nil {
+		return err
+	}
+	err = client.Rcpt(msg.To)
+	if err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildMail renders msg as a multipart/alternative MIME message, text first so clients prefer the HTML.
+func buildMail(from string, msg mailMessage, now time.Time) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	parts := multipart.NewWriter(buf)
+
+	headers := []struct{ name, value string }{
+		{"From", from},
+		{"To", msg.To},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + parts.Boundary()},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(buf, "%s: %s\r\n", header.name, header.value)
+	}
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Quoted-printable keeps lines short and non-ASCII safe
+		qp := quotedprintable.NewWriter(w)
+		_, err = qp.Write([]byte(part.body))
+		if err != nil {
+			return nil, err
+		}
+		err = qp.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := parts.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// validEmail reports whether address is a plain email address, without a display name.
+func validEmail(address string) bool {
+	parsed, err := mail.ParseAddress(address)
+	return err == nil && parsed.Address == address
+}
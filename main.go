@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/PuneethM06/rssagg/internal/database"
@@ -21,9 +26,19 @@ import (
 // Apicurio Schema Registry URL
 const schemaRegistryURL = "http://localhost:9090/api/artifacts/my-schema"
 
+// shutdownTimeout bounds how long we wait for open requests and in-flight fetches on shutdown
+const shutdownTimeout = 30 * time.Second
+
 // apiConfig struct stores the database connection instance
 type apiConfig struct {
-	DB *database.Queries
+	Conn                 *sql.DB // Used for handlers that write in a transaction
+	DB                   *database.Queries
+	Scraper              *scraper         // Used to refresh a feed on demand
+	UserRefreshLimiter   *rateLimiter     // Limits how often one user may ask for a refresh
+	FeedRefreshLimiter   *rateLimiter     // Limits how often one feed may be refreshed, whoever asks
+	Posts                *postBroadcaster // Fans new posts out to the open post streams
+	Mailer               *mailer          // Sends digest confirmations, nil without an SMTP server
+	DigestConfirmLimiter *rateLimiter     // Limits how often a confirmation email goes to one address
 }
 
 // Fetch schema from Apicurio Registry
@@ -65,6 +80,10 @@ func validateJSON(data []byte, schema map[string]interface{}) error {
 }
 
 func main() {
+	// Cancelled on SIGINT or SIGTERM so everything can wind down cleanly
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Load environment variables
 	err := godotenv.Load()
 	if err != nil {
@@ -72,15 +91,26 @@ func main() {
 	}
 
 	// Read required environment variables
-	portString := os.Getenv("PORT")
-	if portString == "" {
-		log.Fatal("$PORT must be set")
-	}
 	dbURL := os.Getenv("DB_URL")
 	if dbURL == "" {
 		log.Fatal("DB_URL must be set")
 	}
 
+	// Bounds for the adaptive per-feed fetch schedule
+	schedule := scheduleConfig{
+		MinInterval: envDuration("FETCH_MIN_INTERVAL", 10*time.Minute),
+		MaxInterval: envDuration("FETCH_MAX_INTERVAL", 24*time.Hour),
+	}
+	if schedule.MinInterval < minFetchInterval {
+		log.Fatalf("FETCH_MIN_INTERVAL must be at least %s", minFetchInterval)
+	}
+	if schedule.MaxInterval <= 0 {
+		log.Fatal("FETCH_MAX_INTERVAL must be greater than 0")
+	}
+	if schedule.MinInterval > schedule.MaxInterval {
+		log.Fatal("FETCH_MIN_INTERVAL must not be greater than FETCH_MAX_INTERVAL")
+	}
+
 	// Establish database connection
 	conn, err := sql.Open("postgres", dbURL)
 	if err != nil {
@@ -89,12 +119,119 @@ func main() {
 
 	// Initialize database queries
 	db := database.New(conn)
+
+	// How long old data is kept before the pruning job removes it
+	retention := retentionConfig{
+		FetchHistory:   envDuration("FETCH_HISTORY_RETENTION", 7*24*time.Hour),
+		PostMaxAgeDays: envInt("POST_RETENTION_DAYS", 0),
+		PostMaxPerFeed: envInt("POST_RETENTION_MAX_PER_FEED", 0),
+		OrphanedFeeds:  envDuration("ORPHANED_FEED_RETENTION", 7*24*time.Hour),
+		WebhookLog:     envDuration("WEBHOOK_DELIVERY_RETENTION", 7*24*time.Hour),
+	}
+
+	// `rssagg prune [-dry-run]` reports (and applies) the retention policy once instead of starting the server
+	if len(os.Args) > 1 && os.Args[1] == "prune" {
+		err = runPruneCommand(ctx, db, retention, os.Args[2:], os.Stdout)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	portString := os.Getenv("PORT")
+	if portString == "" {
+		log.Fatal("$PORT must be set")
+	}
+
+	// Configure the background scraper
+	scr := &scraper{
+		Conn:          conn,
+		DB:            db,
+		Concurrency:   10,
+		PollInterval:  time.Minute,
+		LeaseDuration: envDuration("FETCH_LEASE_DURATION", 5*time.Minute),
+		MaxAttempts:   envInt("FETCH_MAX_ATTEMPTS", 5),
+		Schedule:      schedule,
+	}
+	if scr.LeaseDuration <= fetchTimeout {
+		log.Fatalf("FETCH_LEASE_DURATION must be longer than the %s fetch timeout", fetchTimeout)
+	}
+
+	// Shared state for the API handlers
 	apiCfg := apiConfig{
-		DB: db,
+		Conn:                 conn,
+		DB:                   db,
+		Scraper:              scr,
+		UserRefreshLimiter:   newRateLimiter(10 * time.Second),
+		FeedRefreshLimiter:   newRateLimiter(time.Minute),
+		Posts:                newPostBroadcaster(),
+		DigestConfirmLimiter: newRateLimiter(digestConfirmResendInterval),
 	}
 
 	// Start background scraping
-	go startScrapping(db, 10, time.Minute)
+	if scr.MaxAttempts < 1 {
+		log.Fatal("FETCH_MAX_ATTEMPTS must be at least 1")
+	}
+	scraperDone := make(chan struct{})
+	go func() {
+		defer close(scraperDone)
+		scr.startScrapping(ctx)
+	}()
+
+	// New posts reach the open streams through Postgres, whichever instance stored them
+	go apiCfg.Posts.listenForPosts(ctx, dbURL, db)
+
+	// Start sending webhook deliveries
+	deliverer := &webhookDeliverer{
+		DB:            db,
+		Concurrency:   10,
+		PollInterval:  5 * time.Second,
+		LeaseDuration: time.Minute,
+		MaxAttempts:   envInt("WEBHOOK_MAX_ATTEMPTS", 8),
+	}
+	if deliverer.MaxAttempts < 1 {
+		log.Fatal("WEBHOOK_MAX_ATTEMPTS must be at least 1")
+	}
+	delivererDone := make(chan struct{})
+	go func() {
+		defer close(delivererDone)
+		deliverer.startDelivering(ctx)
+	}()
+
+	// Email digests need an SMTP server; without one they are never sent
+	digestsDone := make(chan struct{})
+	if smtpHost := os.Getenv("SMTP_HOST"); smtpHost != "" {
+		smtpPort := os.Getenv("SMTP_PORT")
+		if smtpPort == "" {
+			smtpPort = "587"
+		}
+		from := os.Getenv("SMTP_FROM")
+		if !validEmail(from) {
+			log.Fatal("SMTP_FROM must be set to an email address when SMTP_HOST is")
+		}
+		digests := &digestScheduler{
+			DB: db,
+			Mailer: &mailer{
+				Addr:     net.JoinHostPort(smtpHost, smtpPort),
+				Username: os.Getenv("SMTP_USERNAME"),
+				Password: os.Getenv("SMTP_PASSWORD"),
+				From:     from,
+			},
+			PollInterval: time.Minute,
+			BatchSize:    20,
+		}
+		apiCfg.Mailer = digests.Mailer
+		go func() {
+			defer close(digestsDone)
+			digests.start(ctx)
+		}()
+	} else {
+		close(digestsDone)
+		log.Println("SMTP_HOST is not set, email digests are disabled")
+	}
+
+	// Start background pruning of expired data
+	go startPruning(ctx, db, time.Hour, retention)
 
 	// Initialize router
 	router := chi.NewRouter()
@@ -102,7 +239,7 @@ func main() {
 	// Enable CORS
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins: []string{"https://*", "http://*"},
-		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
 		ExposedHeaders: []string{"Link"},
 		MaxAge:         300,
@@ -119,17 +256,81 @@ func main() {
 	v1Router.Post("/users", apiCfg.handlerCreateUser)
 	v1Router.Get("/users", apiCfg.middlewareAuth(apiCfg.handlerGetUser))
 
+	// Outbound feeds of a user's timeline, authenticated by the feed token in the URL
+	v1Router.Get("/users/feed_token", apiCfg.middlewareAuth(apiCfg.handlerGetFeedToken))
+	v1Router.Post("/users/feed_token", apiCfg.middlewareAuth(apiCfg.handlerRotateFeedToken))
+	v1Router.Delete("/users/feed_token", apiCfg.middlewareAuth(apiCfg.handlerRevokeFeedToken))
+	v1Router.Get("/users/{userID}/feed.{format}", apiCfg.handlerGetUserFeed)
+
 	// Feed management
 	v1Router.Post("/feeds", apiCfg.middlewareAuth(apiCfg.handlerCreateFeed))
 	v1Router.Get("/feeds", apiCfg.handlerGetFeeds)
+	v1Router.Get("/feeds/{feedID}", apiCfg.handlerGetFeed)
+	v1Router.Patch("/feeds/{feedID}", apiCfg.middlewareAuth(apiCfg.handlerUpdateFeed))
+	v1Router.Delete("/feeds/{feedID}", apiCfg.middlewareAuth(apiCfg.handlerDeleteFeed))
+	v1Router.Post("/feeds/{feedID}/refresh", apiCfg.middlewareAuth(apiCfg.handlerRefreshFeed))
+	v1Router.Get("/feeds/{feedID}/fetches", apiCfg.middlewareAuth(apiCfg.handlerGetFeedFetches))
+	v1Router.Get("/feeds/{feedID}/retention", apiCfg.middlewareAuth(apiCfg.handlerGetFeedRetention))
+	v1Router.Put("/feeds/{feedID}/retention", apiCfg.middlewareAuth(apiCfg.handlerPutFeedRetention))
+	v1Router.Delete("/feeds/{feedID}/retention", apiCfg.middlewareAuth(apiCfg.handlerDeleteFeedRetention))
 
 	// Fetching posts for user
 	v1Router.Get("/posts", apiCfg.middlewareAuth(apiCfg.handlerGetPostsForUser))
+	v1Router.Get("/posts/stream", apiCfg.middlewareAuth(apiCfg.handlerStreamPosts))
+	v1Router.Get("/posts/search", apiCfg.middlewareAuth(apiCfg.handlerSearchPosts))
+	v1Router.Get("/posts/{postID}/duplicates", apiCfg.middlewareAuth(apiCfg.handlerGetPostDuplicates))
+
+	// Read/unread state
+	v1Router.Put("/posts/{postID}/read", apiCfg.middlewareAuth(apiCfg.handlerMarkPostRead))
+	v1Router.Delete("/posts/{postID}/read", apiCfg.middlewareAuth(apiCfg.handlerMarkPostUnread))
+	v1Router.Post("/posts/read", apiCfg.middlewareAuth(apiCfg.handlerMarkPostsRead))
+	v1Router.Post("/posts/unread", apiCfg.middlewareAuth(apiCfg.handlerMarkPostsUnread))
+	v1Router.Get("/posts/unread_counts", apiCfg.middlewareAuth(apiCfg.handlerGetUnreadCounts))
+
+	// Starred posts
+	v1Router.Put("/posts/{postID}/star", apiCfg.middlewareAuth(apiCfg.handlerStarPost))
+	v1Router.Delete("/posts/{postID}/star", apiCfg.middlewareAuth(apiCfg.handlerUnstarPost))
+	v1Router.Get("/posts/starred", apiCfg.middlewareAuth(apiCfg.handlerGetStarredPosts))
 
 	// Feed follow/unfollow
 	v1Router.Post("/feed_follows", apiCfg.middlewareAuth(apiCfg.handlerCreateFeedFollows))
 	v1Router.Get("/feed_follows", apiCfg.middlewareAuth(apiCfg.handlerGetFeedFollows))
+	v1Router.Get("/feed_follows/{feedFollowID}", apiCfg.middlewareAuth(apiCfg.handlerGetFeedFollow))
 	v1Router.Delete("/feed_follows/{feedFollowID}", apiCfg.middlewareAuth(apiCfg.handlerDeleteFeedFollow))
+	v1Router.Put("/feed_follows/{feedFollowID}/folder", apiCfg.middlewareAuth(apiCfg.handlerSetFeedFollowFolder))
+	v1Router.Get("/feeds/{feedID}/follow", apiCfg.middlewareAuth(apiCfg.handlerGetFollowForFeed))
+	v1Router.Delete("/feeds/{feedID}/follow", apiCfg.middlewareAuth(apiCfg.handlerUnfollowFeed))
+
+	// OPML
+	v1Router.Post("/opml", apiCfg.middlewareAuth(apiCfg.handlerImportOPML))
+	v1Router.Get("/opml", apiCfg.middlewareAuth(apiCfg.handlerExportOPML))
+
+	// Folders
+	v1Router.Post("/folders", apiCfg.middlewareAuth(apiCfg.handlerCreateFolder))
+	v1Router.Get("/folders", apiCfg.middlewareAuth(apiCfg.handlerGetFolders))
+	v1Router.Put("/folders/{folderID}", apiCfg.middlewareAuth(apiCfg.handlerRenameFolder))
+	v1Router.Delete("/folders/{folderID}", apiCfg.middlewareAuth(apiCfg.handlerDeleteFolder))
+
+	// Filter rules hide matching posts from the timeline
+	v1Router.Post("/filter_rules", apiCfg.middlewareAuth(apiCfg.handlerCreateFilterRule))
+	v1Router.Get("/filter_rules", apiCfg.middlewareAuth(apiCfg.handlerGetFilterRules))
+	v1Router.Delete("/filter_rules/{ruleID}", apiCfg.middlewareAuth(apiCfg.handlerDeleteFilterRule))
+
+	// Email digests
+	v1Router.Get("/digest", apiCfg.middlewareAuth(apiCfg.handlerGetDigestSettings))
+	v1Router.Put("/digest", apiCfg.middlewareAuth(apiCfg.handlerPutDigestSettings))
+	v1Router.Delete("/digest", apiCfg.middlewareAuth(apiCfg.handlerDeleteDigestSettings))
+	v1Router.Get("/digest/confirm", apiCfg.handlerConfirmDigestEmail)
+
+	// Webhooks
+	v1Router.Post("/webhooks", apiCfg.middlewareAuth(apiCfg.handlerCreateWebhook))
+	v1Router.Get("/webhooks", apiCfg.middlewareAuth(apiCfg.handlerGetWebhooks))
+	v1Router.Delete("/webhooks/{webhookID}", apiCfg.middlewareAuth(apiCfg.handlerDeleteWebhook))
+	v1Router.Get("/webhooks/{webhookID}/deliveries", apiCfg.middlewareAuth(apiCfg.handlerGetWebhookDeliveries))
+
+	// Scrape job queue administration
+	v1Router.Get("/admin/scrape_jobs", apiCfg.middlewareAdmin(apiCfg.handlerGetScrapeJobs))
+	v1Router.Post("/admin/scrape_jobs/{jobID}/retry", apiCfg.middlewareAdmin(apiCfg.handlerRetryScrapeJob))
 
 	// Schema validation endpoint
 	v1Router.Post("/validate", func(w http.ResponseWriter, r *http.Request) {
@@ -163,10 +364,48 @@ func main() {
 		Addr:    ":" + portString,
 	}
 
+	// Open streams would otherwise keep Shutdown waiting until it times out
+	srv.RegisterOnShutdown(apiCfg.Posts.shutdown)
+
 	log.Printf("Listening on port %s\n", portString)
 
-	err = srv.ListenAndServe()
+	go func() {
+		err := srv.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Println("Server error:", err)
+			stop() // Take the scraper down with the server
+		}
+	}()
+
+	// Block until we are asked to stop
+	<-ctx.Done()
+	log.Println("Shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Stop accepting requests and let the open ones finish
+	err = srv.Shutdown(shutdownCtx)
 	if err != nil {
-		log.Fatal(err)
+		log.Println("Error shutting down server:", err)
 	}
+
+	// The scraper stops dispatching on its own; wait for the fetches it already started
+	select {
+	case <-scraperDone:
+	case <-shutdownCtx.Done():
+		log.Println("Timed out waiting for in-flight fetches")
+	}
+	select {
+	case <-delivererDone:
+	case <-shutdownCtx.Done():
+		log.Println("Timed out waiting for in-flight webhook deliveries")
+	}
+	select {
+	case <-digestsDone:
+	case <-shutdownCtx.Done():
+		log.Println("Timed out waiting for in-flight digests")
+	}
+
+	conn.Close()
 }
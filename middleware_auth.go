@@ -32,3 +32,15 @@ func (apiCfg *apiConfig) middlewareAuth(handler authHandler) http.HandlerFunc {
 		handler(w, r, user)
 	}
 }
+
+// Middleware function that only lets authenticated admin users through
+func (apiCfg *apiConfig) middlewareAdmin(handler authHandler) http.HandlerFunc {
+	return apiCfg.middlewareAuth(func(w http.ResponseWriter, r *http.Request, user database.User) {
+		if !user.IsAdmin {
+			respondWithError(w, http.StatusForbidden, "Forbidden")
+			return
+		}
+
+		handler(w, r, user)
+	})
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/PuneethM06/rssagg/internal/database"
@@ -15,20 +17,31 @@ type User struct {
 }
 
 type Feed struct {
-	ID        uuid.UUID     `json:"id"`
-	CreatedAt time.Time     `json:"created_at"`
-	UpdatedAt time.Time     `json:"updated_at"`
-	Name      string        `json:"name"`
-	Url       string        `json:"url"`
-	UserID    uuid.NullUUID `json:"user_id"`
+	ID          uuid.UUID     `json:"id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	Name        string        `json:"name"`
+	Url         string        `json:"url"`
+	SiteUrl     *string       `json:"site_url"`
+	UserID      uuid.NullUUID `json:"user_id"`
+	NextFetchAt *time.Time    `json:"next_fetch_at"`
 }
 
 type FeedFollows struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	UserID    uuid.UUID `json:"user_id"`
-	FeedID    uuid.UUID `json:"feed_id"`
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	UserID    uuid.UUID  `json:"user_id"`
+	FeedID    uuid.UUID  `json:"feed_id"`
+	FolderID  *uuid.UUID `json:"folder_id"`
+}
+
+// FeedToken is the secret for a user's outbound feeds, with the URLs that use it
+type FeedToken struct {
+	Token   string `json:"token"`
+	RSSURL  string `json:"rss_url"`
+	AtomURL string `json:"atom_url"`
+	JSONURL string `json:"json_url"`
 }
 
 func databaseUserToUser(dbUser database.User) User {
@@ -47,13 +60,25 @@ func databaseFeedToFeed(dbFeed database.Feed) Feed {
 		userID.Valid = true
 	}
 
+	var nextFetchAt *time.Time
+	if dbFeed.NextFetchAt.Valid {
+		nextFetchAt = &dbFeed.NextFetchAt.Time
+	}
+
+	var siteURL *string
+	if dbFeed.SiteUrl.Valid {
+		siteURL = &dbFeed.SiteUrl.String
+	}
+
 	return Feed{
-		ID:        dbFeed.ID,
-		CreatedAt: dbFeed.CreatedAt,
-		UpdatedAt: dbFeed.UpdatedAt,
-		Name:      dbFeed.Name,
-		Url:       dbFeed.Url,
-		UserID:    userID, // Properly handled nullable UUID
+		ID:          dbFeed.ID,
+		CreatedAt:   dbFeed.CreatedAt,
+		UpdatedAt:   dbFeed.UpdatedAt,
+		Name:        dbFeed.Name,
+		Url:         dbFeed.Url,
+		SiteUrl:     siteURL,
+		UserID:      userID, // Properly handled nullable UUID
+		NextFetchAt: nextFetchAt,
 	}
 }
 
@@ -66,12 +91,17 @@ func databaseFeedsToFeeds(dbFeeds []database.Feed) []Feed {
 }
 
 func databaseFeedFollowToFeedFollow(dbFeedFollow database.FeedFollow) FeedFollows {
+	var folderID *uuid.UUID
+	if dbFeedFollow.FolderID.Valid {
+		folderID = &dbFeedFollow.FolderID.UUID
+	}
 	return FeedFollows{
 		ID:        dbFeedFollow.ID,
 		CreatedAt: dbFeedFollow.CreatedAt,
 		UpdatedAt: dbFeedFollow.UpdatedAt,
 		UserID:    dbFeedFollow.UserID,
 		FeedID:    dbFeedFollow.FeedID,
+		FolderID:  folderID,
 	}
 }
 
@@ -83,16 +113,44 @@ func databaseFeedFollowsToFeedFollows(dbFeedFollows []database.FeedFollow) []Fee
 	return feedFollows
 }
 
+type Folder struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+}
+
+func databaseFolderToFolder(dbFolder database.Folder) Folder {
+	return Folder{
+		ID:        dbFolder.ID,
+		CreatedAt: dbFolder.CreatedAt,
+		UpdatedAt: dbFolder.UpdatedAt,
+		Name:      dbFolder.Name,
+	}
+}
+
+func databaseFoldersToFolders(dbFolders []database.Folder) []Folder {
+	folders := make([]Folder, len(dbFolders))
+	for i, dbFolder := range dbFolders {
+		folders[i] = databaseFolderToFolder(dbFolder)
+	}
+	return folders
+}
+
 type Post struct {
-	ID          uuid.UUID `json:"id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Name        string    `json:"name"`
-	Title       string    `json:"title"`
-	Description *string   `json:"description"`
-	PublishedAt time.Time `json:"published_at"`
-	Url         string    `json:"url"`
-	FeedID      uuid.UUID `json:"feed_id"`
+	ID           uuid.UUID `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Name         string    `json:"name"`
+	Title        string    `json:"title"`
+	Description  *string   `json:"description"`
+	Content      *string   `json:"content"`
+	PublishedAt  time.Time `json:"published_at"`
+	Url          string    `json:"url"`
+	CanonicalUrl string    `json:"canonical_url"`
+	FeedID       uuid.UUID `json:"feed_id"`
+	Author       *string   `json:"author"`
+	Categories   []string  `json:"categories"`
 }
 
 func databasePosttoPost(dbPost database.Post) Post {
@@ -100,15 +158,32 @@ func databasePosttoPost(dbPost database.Post) Post {
 	if dbPost.Description.Valid {
 		description = &dbPost.Description.String
 	}
+	var content *string
+	if dbPost.Content.Valid {
+		content = &dbPost.Content.String
+	}
+	var author *string
+	if dbPost.Author.Valid {
+		author = &dbPost.Author.String
+	}
+	categories := dbPost.Categories
+	if categories == nil {
+		categories = []string{}
+	}
 	return Post{
-		ID:          dbPost.ID,
-		CreatedAt:   dbPost.CreatedAt,
-		UpdatedAt:   dbPost.UpdatedAt,
-		Name:        dbPost.Name,
-		Title:       *description,
-		PublishedAt: dbPost.PublishedAt,
-		Url:         dbPost.Url,
-		FeedID:      dbPost.FeedID,
+		ID:           dbPost.ID,
+		CreatedAt:    dbPost.CreatedAt,
+		UpdatedAt:    dbPost.UpdatedAt,
+		Name:         dbPost.Name,
+		Title:        dbPost.Title,
+		Description:  description,
+		Content:      content,
+		PublishedAt:  dbPost.PublishedAt,
+		Url:          dbPost.Url,
+		CanonicalUrl: dbPost.CanonicalUrl,
+		FeedID:       dbPost.FeedID,
+		Author:       author,
+		Categories:   categories,
 	}
 }
 
@@ -120,3 +195,377 @@ func databasePostsToPosts(dbPosts []database.Post) []Post {
 	return posts
 
 }
+
+type ScrapeJob struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	FeedID         uuid.UUID `json:"feed_id"`
+	Status         string    `json:"status"`
+	Attempts       int32     `json:"attempts"`
+	RunAt          time.Time `json:"run_at"`
+	LastError      *string   `json:"last_error"`
+	LastStatusCode *int32    `json:"last_status_code"`
+}
+
+func databaseScrapeJobToScrapeJob(dbJob database.ScrapeJob) ScrapeJob {
+	var lastError *string
+	if dbJob.LastError.Valid {
+		lastError = &dbJob.LastError.String
+	}
+	var lastStatusCode *int32
+	if dbJob.LastStatusCode.Valid {
+		lastStatusCode = &dbJob.LastStatusCode.Int32
+	}
+
+	return ScrapeJob{
+		ID:             dbJob.ID,
+		CreatedAt:      dbJob.CreatedAt,
+		UpdatedAt:      dbJob.UpdatedAt,
+		FeedID:         dbJob.FeedID,
+		Status:         dbJob.Status,
+		Attempts:       dbJob.Attempts,
+		RunAt:          dbJob.RunAt,
+		LastError:      lastError,
+		LastStatusCode: lastStatusCode,
+	}
+}
+
+func databaseScrapeJobsToScrapeJobs(dbJobs []database.ScrapeJob) []ScrapeJob {
+	jobs := make([]ScrapeJob, len(dbJobs))
+	for i, dbJob := range dbJobs {
+		jobs[i] = databaseScrapeJobToScrapeJob(dbJob)
+	}
+	return jobs
+}
+
+type FeedFetch struct {
+	ID            uuid.UUID `json:"id"`
+	FeedID        uuid.UUID `json:"feed_id"`
+	FetchedAt     time.Time `json:"fetched_at"`
+	StatusCode    *int32    `json:"status_code"`
+	Bytes         int64     `json:"bytes"`
+	DurationMs    int32     `json:"duration_ms"`
+	ItemsSeen     int32     `json:"items_seen"`
+	ItemsInserted int32     `json:"items_inserted"`
+	Error         *string   `json:"error"`
+}
+
+func databaseFeedFetchToFeedFetch(dbFetch database.FeedFetch) FeedFetch {
+	var statusCode *int32
+	if dbFetch.StatusCode.Valid {
+		statusCode = &dbFetch.StatusCode.Int32
+	}
+	var fetchErr *string
+	if dbFetch.Error.Valid {
+		fetchErr = &dbFetch.Error.String
+	}
+
+	return FeedFetch{
+		ID:            dbFetch.ID,
+		FeedID:        dbFetch.FeedID,
+		FetchedAt:     dbFetch.FetchedAt,
+		StatusCode:    statusCode,
+		Bytes:         dbFetch.Bytes,
+		DurationMs:    dbFetch.DurationMs,
+		ItemsSeen:     dbFetch.ItemsSeen,
+		ItemsInserted: dbFetch.ItemsInserted,
+		Error:         fetchErr,
+	}
+}
+
+func databaseFeedFetchesToFeedFetches(dbFetches []database.FeedFetch) []FeedFetch {
+	fetches := make([]FeedFetch, len(dbFetches))
+	for i, dbFetch := range dbFetches {
+		fetches[i] = databaseFeedFetchToFeedFetch(dbFetch)
+	}
+	return fetches
+}
+
+type FeedRetention struct {
+	FeedID     uuid.UUID `json:"feed_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	MaxAgeDays *int32    `json:"max_age_days"`
+	MaxPosts   *int32    `json:"max_posts"`
+}
+
+func databaseFeedRetentionToFeedRetention(dbRetention database.FeedRetention) FeedRetention {
+	var maxAgeDays *int32
+	if dbRetention.MaxAgeDays.Valid {
+		maxAgeDays = &dbRetention.MaxAgeDays.Int32
+	}
+	var maxPosts *int32
+	if dbRetention.MaxPosts.Valid {
+		maxPosts = &dbRetention.MaxPosts.Int32
+	}
+
+	return FeedRetention{
+		FeedID:     dbRetention.FeedID,
+		CreatedAt:  dbRetention.CreatedAt,
+		UpdatedAt:  dbRetention.UpdatedAt,
+		MaxAgeDays: maxAgeDays,
+		MaxPosts:   maxPosts,
+	}
+}
+
+type TimelinePost struct {
+	Post
+	Read    bool `json:"read"`
+	Starred bool `json:"starred"`
+	Hidden  bool `json:"hidden"` // Matches one of the user's filter rules, only listed with show_hidden
+}
+
+func databaseTimelineRowsToTimelinePosts(rows []database.GetPostsForUserRow) []TimelinePost {
+	posts := []TimelinePost{}
+	for _, row := range rows {
+		posts = append(posts, TimelinePost{
+			Post:    databasePosttoPost(row.Post),
+			Read:    row.Read,
+			Starred: row.Starred,
+			Hidden:  row.Hidden,
+		})
+	}
+	return posts
+}
+
+func databaseDuplicateRowsToTimelinePosts(rows []database.GetPostDuplicatesRow) []TimelinePost {
+	posts := []TimelinePost{}
+	for _, row := range rows {
+		posts = append(posts, TimelinePost{
+			Post:    databasePosttoPost(row.Post),
+			Read:    row.Read,
+			Starred: row.Starred,
+		})
+	}
+	return posts
+}
+
+type StarredPost struct {
+	TimelinePost
+	StarredAt time.Time `json:"starred_at"`
+}
+
+func databaseStarredRowsToStarredPosts(rows []database.GetStarredPostsRow) []StarredPost {
+	posts := []StarredPost{}
+	for _, row := range rows {
+		posts = append(posts, StarredPost{
+			TimelinePost: TimelinePost{
+				Post:    databasePosttoPost(row.Post),
+				Read:    row.Read,
+				Starred: true,
+			},
+			StarredAt: row.StarredAt,
+		})
+	}
+	return posts
+}
+
+type UnreadCount struct {
+	FeedID uuid.UUID `json:"feed_id"`
+	Unread int64     `json:"unread"`
+}
+
+func databaseUnreadCountsToUnreadCounts(rows []database.GetUnreadCountsRow) []UnreadCount {
+	counts := []UnreadCount{}
+	for _, row := range rows {
+		counts = append(counts, UnreadCount{FeedID: row.FeedID, Unread: row.Unread})
+	}
+	return counts
+}
+
+type PostSearchResult struct {
+	TimelinePost
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"` // Matching fragments with the hits wrapped in <mark>
+}
+
+func databaseSearchRowsToPostSearchResults(rows []database.SearchPostsForUserRow) []PostSearchResult {
+	results := []PostSearchResult{}
+	for _, row := range rows {
+		results = append(results, PostSearchResult{
+			TimelinePost: TimelinePost{
+				Post:    databasePosttoPost(row.Post),
+				Read:    row.Read,
+				Starred: row.Starred,
+			},
+			Rank:    row.Rank,
+			Snippet: row.Snippet,
+		})
+	}
+	return results
+}
+
+type Webhook struct {
+	ID        uuid.UUID   `json:"id"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	Url       string      `json:"url"`
+	Secret    string      `json:"secret"` // Key for the X-Rssagg-Signature header
+	FeedIDs   []uuid.UUID `json:"feed_ids"`
+	Keywords  []string    `json:"keywords"`
+}
+
+func databaseWebhookToWebhook(dbWebhook database.Webhook) Webhook {
+	feedIDs := dbWebhook.FeedIds
+	if feedIDs == nil {
+		feedIDs = []uuid.UUID{}
+	}
+	keywords := dbWebhook.Keywords
+	if keywords == nil {
+		keywords = []string{}
+	}
+
+	return Webhook{
+		ID:        dbWebhook.ID,
+		CreatedAt: dbWebhook.CreatedAt,
+		UpdatedAt: dbWebhook.UpdatedAt,
+		Url:       dbWebhook.Url,
+		Secret:    dbWebhook.Secret,
+		FeedIDs:   feedIDs,
+		Keywords:  keywords,
+	}
+}
+
+func databaseWebhooksToWebhooks(dbWebhooks []database.Webhook) []Webhook {
+	webhooks := make([]Webhook, len(dbWebhooks))
+	for i, dbWebhook := range dbWebhooks {
+		webhooks[i] = databaseWebhookToWebhook(dbWebhook)
+	}
+	return webhooks
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID  `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	PostID         uuid.UUID  `json:"post_id"`
+	Status         string     `json:"status"`
+	Attempts       int32      `json:"attempts"`
+	RunAt          time.Time  `json:"run_at"` // Next attempt while the delivery is pending
+	LastStatusCode *int32     `json:"last_status_code"`
+	LastError      *string    `json:"last_error"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+func databaseWebhookDeliveryToWebhookDelivery(dbDelivery database.WebhookDelivery) WebhookDelivery {
+	var lastStatusCode *int32
+	if dbDelivery.LastStatusCode.Valid {
+		lastStatusCode = &dbDelivery.LastStatusCode.Int32
+	}
+	var lastError *string
+	if dbDelivery.LastError.Valid {
+		lastError = &dbDelivery.LastError.String
+	}
+	var deliveredAt *time.Time
+	if dbDelivery.DeliveredAt.Valid {
+		deliveredAt = &dbDelivery.DeliveredAt.Time
+	}
+
+	return WebhookDelivery{
+		ID:             dbDelivery.ID,
+		CreatedAt:      dbDelivery.CreatedAt,
+		UpdatedAt:      dbDelivery.UpdatedAt,
+		PostID:         dbDelivery.PostID,
+		Status:         dbDelivery.Status,
+		Attempts:       dbDelivery.Attempts,
+		RunAt:          dbDelivery.RunAt,
+		LastStatusCode: lastStatusCode,
+		LastError:      lastError,
+		DeliveredAt:    deliveredAt,
+	}
+}
+
+func databaseWebhookDeliveriesToWebhookDeliveries(dbDeliveries []database.WebhookDelivery) []WebhookDelivery {
+	deliveries := make([]WebhookDelivery, len(dbDeliveries))
+	for i, dbDelivery := range dbDeliveries {
+		deliveries[i] = databaseWebhookDeliveryToWebhookDelivery(dbDelivery)
+	}
+	return deliveries
+}
+
+type DigestSettings struct {
+	Email      string      `json:"email"`
+	Frequency  string      `json:"frequency"`
+	TimeOfDay  string      `json:"time_of_day"`
+	Weekday    *string     `json:"weekday"`
+	Timezone   string      `json:"timezone"`
+	FolderIDs  []uuid.UUID `json:"folder_ids"`
+	NextSendAt time.Time   `json:"next_send_at"`
+	LastSentAt *time.Time  `json:"last_sent_at"`
+	// Nothing is sent until the link mailed to the address has been opened
+	EmailConfirmed bool `json:"email_confirmed"`
+}
+
+func databaseDigestSettingToDigestSettings(dbSettings database.DigestSetting) DigestSettings {
+	var weekday *string
+	if dbSettings.Weekday.Valid {
+		day := strings.ToLower(time.Weekday(dbSettings.Weekday.Int32).String())
+		weekday = &day
+	}
+	var lastSentAt *time.Time
+	if dbSettings.LastSentAt.Valid {
+		lastSentAt = &dbSettings.LastSentAt.Time
+	}
+	folderIDs := dbSettings.FolderIds
+	if folderIDs == nil {
+		folderIDs = []uuid.UUID{}
+	}
+
+	return DigestSettings{
+		Email:          dbSettings.Email,
+		Frequency:      dbSettings.Frequency,
+		TimeOfDay:      fmt.Sprintf("%02d:%02d", dbSettings.MinuteOfDay/60, dbSettings.MinuteOfDay%60),
+		Weekday:        weekday,
+		Timezone:       dbSettings.Timezone,
+		FolderIDs:      folderIDs,
+		NextSendAt:     dbSettings.NextSendAt,
+		LastSentAt:     lastSentAt,
+		EmailConfirmed: dbSettings.EmailConfirmedAt.Valid,
+	}
+}
+
+type FilterRule struct {
+	ID              uuid.UUID  `json:"id"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	FeedID          *uuid.UUID `json:"feed_id"` // null when the rule applies to every feed
+	Field           string     `json:"field"`
+	MatchType       string     `json:"match_type"`
+	Pattern         string     `json:"pattern"`
+	SuppressedCount int64      `json:"suppressed_count"` // Posts of the user's follows the rule hides
+}
+
+func databaseFilterRuleToFilterRule(dbRule database.FilterRule, suppressedCount int64) FilterRule {
+	var feedID *uuid.UUID
+	if dbRule.FeedID.Valid {
+		feedID = &dbRule.FeedID.UUID
+	}
+	return FilterRule{
+		ID:              dbRule.ID,
+		CreatedAt:       dbRule.CreatedAt,
+		UpdatedAt:       dbRule.UpdatedAt,
+		FeedID:          feedID,
+		Field:           dbRule.Field,
+		MatchType:       dbRule.MatchType,
+		Pattern:         dbRule.Pattern,
+		SuppressedCount: suppressedCount,
+	}
+}
+
+func databaseFilterRuleRowsToFilterRules(rows []database.GetFilterRulesWithCountsRow) []FilterRule {
+	rules := []FilterRule{}
+	for _, row := range rows {
+		rules = append(rules, databaseFilterRuleToFilterRule(database.FilterRule{
+			ID:        row.ID,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+			UserID:    row.UserID,
+			FeedID:    row.FeedID,
+			Field:     row.Field,
+			MatchType: row.MatchType,
+			Pattern:   row.Pattern,
+		}, row.SuppressedCount))
+	}
+	return rules
+}
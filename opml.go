@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// opmlDocument is an OPML 1.0 or 2.0 subscription list.
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    struct {
+		Title       string `xml:"title,omitempty"`
+		DateCreated string `xml:"dateCreated,omitempty"`
+	} `xml:"head"`
+	Body struct {
+		Outlines []opmlOutline `xml:"outline"`
+	} `xml:"body"`
+}
+
+// opmlOutline is either a subscription (it has an xmlUrl) or a group of outlines.
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// opmlEntry is one subscription found in an OPML document.
+type opmlEntry struct {
+	Title   string
+	XMLURL  string
+	HTMLURL string
+	Folder  string // Names of the enclosing outlines joined with " / ", empty at the top level
+}
+
+// parseOPML reads the subscriptions out of an OPML document, in document order.
+func parseOPML(r io.Reader) ([]opmlEntry, error) {
+	doc := opmlDocument{}
+	err := xml.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []opmlEntry{}
+	var walk func(outlines []opmlOutline, path []string)
+	walk = func(outlines []opmlOutline, path []string) {
+		for _, outline := range outlines {
+			// OPML 1.0 files often only set text, some exporters only title
+			name := strings.TrimSpace(outline.Text)
+			if name == "" {
+				name = strings.TrimSpace(outline.Title)
+			}
+
+			if url := strings.TrimSpace(outline.XMLURL); url != "" {
+				entries = append(entries, opmlEntry{
+					Title:   name,
+					XMLURL:  url,
+					HTMLURL: strings.TrimSpace(outline.HTMLURL),
+					Folder:  strings.Join(path, " / "),
+				})
+			}
+
+			// Our folders are flat, so nested groups become a folder named after their whole path
+			childPath := path
+			if outline.XMLURL == "" && name != "" {
+				childPath = append(append([]string{}, path...), name)
+			}
+			walk(outline.Outlines, childPath)
+		}
+	}
+	walk(doc.Body.Outlines, nil)
+
+	return entries, nil
+}
+
+// buildOPML renders subscriptions as an OPML 2.0 document. Folders named like "Tech / Databases"
+// become nested outlines again, so an export can be imported back into the same folders.
+func buildOPML(title string, created time.Time, entries []opmlEntry) opmlDocument {
+	doc := opmlDocument{Version: "2.0"}
+	doc.Head.Title = title
+	doc.Head.DateCreated = created.UTC().Format(time.RFC1123Z)
+
+	for _, entry := range entries {
+		// Walk down the folder path, reusing groups that already exist at each level
+		outlines := &doc.Body.Outlines
+		if entry.Folder != "" {
+			for _, name := range strings.Split(entry.Folder, " / ") {
+				i := slices.IndexFunc(*outlines, func(o opmlOutline) bool { return o.XMLURL == "" && o.Text == name })
+				if i < 0 {
+					*outlines = append(*outlines, opmlOutline{Text: name, Title: name})
+					i = len(*outlines) - 1
+				}
+				outlines = &(*outlines)[i].Outlines
+			}
+		}
+
+		*outlines = append(*outlines, opmlOutline{
+			Text:    entry.Title,
+			Title:   entry.Title,
+			Type:    "rss",
+			XMLURL:  entry.XMLURL,
+			HTMLURL: entry.HTMLURL,
+		})
+	}
+
+	return doc
+}
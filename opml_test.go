@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseOPML(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head><title>Subscriptions</title></head>
+  <body>
+    <outline text="Top level" type="rss" xmlUrl=" https://example.com/feed.xml " htmlUrl="https://example.com/"/>
+    <outline text="Tech">
+      <outline title="Go Blog" type="rss" xmlUrl="https://go.dev/blog/feed.atom"/>
+      <outline text="Databases">
+        <outline text="Postgres" xmlUrl="https://www.postgresql.org/news.rss"/>
+      </outline>
+    </outline>
+    <outline text="Empty group"/>
+  </body>
+</opml>`
+
+	entries, err := parseOPML(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("parseOPML() error = %v", err)
+	}
+
+	want := []opmlEntry{
+		{Title: "Top level", XMLURL: "https://example.com/feed.xml", HTMLURL: "https://example.com/"},
+		{Title: "Go Blog", XMLURL: "https://go.dev/blog/feed.atom", Folder: "Tech"},
+		{Title: "Postgres", XMLURL: "https://www.postgresql.org/news.rss", Folder: "Tech / Databases"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("parseOPML() = %+v, want %+v", entries, want)
+	}
+}
+
+func TestParseOPMLRejectsOtherDocuments(t *testing.T) {
+	_, err := parseOPML(strings.NewReader(`<rss version="2.0"><channel></channel></rss>`))
+	if err == nil {
+		t.Error("parseOPML() succeeded on an RSS document, want an error")
+	}
+}
+
+func TestBuildOPMLRoundTrip(t *testing.T) {
+	entries := []opmlEntry{
+		{Title: "Unfiled", XMLURL: "https://example.com/feed.xml", HTMLURL: "https://example.com/"},
+		{Title: "Go Blog", XMLURL: "https://go.dev/blog/feed.atom", Folder: "Tech"},
+		{Title: "Postgres", XMLURL: "https://www.postgresql.org/news.rss", Folder: "Tech / Databases"},
+		{Title: "Rust Blog", XMLURL: "https://blog.rust-lang.org/feed.xml", Folder: "Tech"},
+	}
+
+	doc := buildOPML("Subscriptions", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), entries)
+	data, err := xml.Marshal(doc)
+	if err != nil {
+		t.Fatalf("xml.Marshal() error = %v", err)
+	}
+	if len(doc.Body.Outlines) != 2 {
+		t.Errorf("got %d top-level outlines, want 2 (the unfiled feed and Tech)", len(doc.Body.Outlines))
+	}
+
+	got, err := parseOPML(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("parseOPML() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, entries) {
+		t.Errorf("parseOPML(buildOPML()) = %+v, want %+v", got, entries)
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// outboundFeed is a list of posts we publish as RSS, Atom or JSON Feed.
+type outboundFeed struct {
+	ID      uuid.UUID // Stable ID for the feed, used in the Atom id
+	Title   string
+	Author  string
+	SelfURL string // Absolute URL the feed is served from
+	Updated time.Time
+	Items   []outboundItem
+}
+
+// outboundItem is one post in an outboundFeed.
+type outboundItem struct {
+	ID        uuid.UUID
+	Title     string
+	URL       string
+	Summary   string // HTML, may be empty
+	Content   string // HTML, may be empty
+	Published time.Time
+	Updated   time.Time
+}
+
+// outboundFeedFormats maps the extension in the URL to the content type it is served with.
+var outboundFeedFormats = map[string]string{
+	"rss":  "application/rss+xml; charset=utf-8",
+	"atom": "application/atom+xml; charset=utf-8",
+	"json": "application/feed+json; charset=utf-8",
+}
+
+// renderOutboundFeed renders the feed in one of outboundFeedFormats.
+func renderOutboundFeed(feed outboundFeed, format string) ([]byte, error) {
+	switch format {
+	case "rss":
+		return renderRSS(feed)
+	case "atom":
+		return renderAtom(feed)
+	default:
+		return renderJSONFeed(feed)
+	}
+}
+
+func urnUUID(id uuid.UUID) string {
+	return "urn:uuid:" + id.String()
+}
+
+type rssOutDocument struct {
+	XMLName          xml.Name      `xml:"rss"`
+	Version          string        `xml:"version,attr"`
+	AtomNamespace    string        `xml:"xmlns:atom,attr"`
+	ContentNamespace string        `xml:"xmlns:content,attr"`
+	Channel          rssOutChannel `xml:"channel"`
+}
+
+type rssOutChannel struct {
+	Title         string `xml:"title"`
+	Link          string `xml:"link"`
+	Description   string `xml:"description"`
+	LastBuildDate string `xml:"lastBuildDate"`
+	Self          struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"atom:link"`
+	Items []rssOutItem `xml:"item"`
+}
+
+type rssOutItem struct {
+	Title string `xml:"title"`
+	Link  string `xml:"link,omitempty"`
+	GUID  struct {
+		IsPermaLink string `xml:"isPermaLink,attr"`
+		Value       string `xml:",chardata"`
+	} `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Description string `xml:"description,omitempty"`
+	Content     string `xml:"content:encoded,omitempty"`
+}
+
+// renderRSS renders the feed as RSS 2.0.
+func renderRSS(feed outboundFeed) ([]byte, error) {
+	doc := rssOutDocument{
+		Version:          "2.0",
+		AtomNamespace:    "http://www.w3.org/2005/Atom",
+		ContentNamespace: "http://purl.org/rss/1.0/modules/content/",
+	}
+	doc.Channel.Title = feed.Title
+	doc.Channel.Link = feed.SelfURL
+	doc.Channel.Description = feed.Title
+	doc.Channel.LastBuildDate = feed.Updated.Format(time.RFC1123Z)
+	doc.Channel.Self.Href = feed.SelfURL
+	doc.Channel.Self.Rel = "self"
+	doc.Channel.Self.Type = "application/rss+xml"
+
+	doc.Channel.Items = []rssOutItem{}
+	for _, item := range feed.Items {
+		out := rssOutItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			PubDate:     item.Published.Format(time.RFC1123Z),
+			Description: item.Summary,
+			Content:     item.Content,
+		}
+		out.GUID.IsPermaLink = "false"
+		out.GUID.Value = urnUUID(item.ID)
+		doc.Channel.Items = append(doc.Channel.Items, out)
+	}
+
+	return marshalXMLDocument(doc)
+}
+
+type atomOutFeed struct {
+	XMLName xml.Name       `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string         `xml:"title"`
+	ID      string         `xml:"id"`
+	Updated string         `xml:"updated"`
+	Link    atomOutLink    `xml:"link"`
+	Author  atomOutAuthor  `xml:"author"`
+	Entries []atomOutEntry `xml:"entry"`
+}
+
+type atomOutLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomOutAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomOutText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomOutEntry struct {
+	Title     string       `xml:"title"`
+	ID        string       `xml:"id"`
+	Link      *atomOutLink `xml:"link"`
+	Published string       `xml:"published"`
+	Updated   string       `xml:"updated"`
+	Summary   *atomOutText `xml:"summary"`
+	Content   *atomOutText `xml:"content"`
+}
+
+// renderAtom renders the feed as Atom 1.0.
+func renderAtom(feed outboundFeed) ([]byte, error) {
+	doc := atomOutFeed{
+		Title:   feed.Title,
+		ID:      urnUUID(feed.ID),
+		Updated: feed.Updated.Format(time.RFC3339),
+		Link:    atomOutLink{Rel: "self", Href: feed.SelfURL},
+		Author:  atomOutAuthor{Name: feed.Author},
+		Entries: []atomOutEntry{},
+	}
+	for _, item := range feed.Items {
+		entry := atomOutEntry{
+			Title:     item.Title,
+			ID:        urnUUID(item.ID),
+			Published: item.Published.Format(time.RFC3339),
+			Updated:   item.Updated.Format(time.RFC3339),
+		}
+		if item.URL != "" {
+			entry.Link = &atomOutLink{Rel: "alternate", Href: item.URL}
+		}
+		if item.Summary != "" {
+			entry.Summary = &atomOutText{Type: "html", Value: item.Summary}
+		}
+		if item.Content != "" {
+			entry.Content = &atomOutText{Type: "html", Value: item.Content}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return marshalXMLDocument(doc)
+}
+
+func marshalXMLDocument(doc interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+type jsonFeedOut struct {
+	Version string            `json:"version"`
+	Title   string            `json:"title"`
+	FeedURL string            `json:"feed_url"`
+	Authors []jsonFeedAuthor  `json:"authors,omitempty"`
+	Items   []jsonFeedOutItem `json:"items"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedOutItem struct {
+	ID            string `json:"id"`
+	URL           string `json:"url,omitempty"`
+	Title         string `json:"title"`
+	ContentHTML   string `json:"content_html"`
+	Summary       string `json:"summary,omitempty"`
+	DatePublished string `json:"date_published"`
+	DateModified  string `json:"date_modified"`
+}
+
+// renderJSONFeed renders the feed as JSON Feed 1.1.
+func renderJSONFeed(feed outboundFeed) ([]byte, error) {
+	doc := jsonFeedOut{
+		Version: "https://jsonfeed.org/version/1.1",
+		Title:   feed.Title,
+		FeedURL: feed.SelfURL,
+		Items:   []jsonFeedOutItem{},
+	}
+	if feed.Author != "" {
+		doc.Authors = []jsonFeedAuthor{{Name: feed.Author}}
+	}
+	for _, item := range feed.Items {
+		// Every item needs content_html or content_text, so fall back to the summary
+		content := item.Content
+		if content == "" {
+			content = item.Summary
+		}
+		doc.Items = append(doc.Items, jsonFeedOutItem{
+			ID:            urnUUID(item.ID),
+			URL:           item.URL,
+			Title:         item.Title,
+			ContentHTML:   content,
+			Summary:       item.Summary,
+			DatePublished: item.Published.Format(time.RFC3339),
+			DateModified:  item.Updated.Format(time.RFC3339),
+		})
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func testOutboundFeed() outboundFeed {
+	published := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	return outboundFeed{
+		ID:      uuid.MustParse("7d444840-9dc0-11d1-b245-5ffdce74fad2"),
+		Title:   "Ada's feed",
+		Author:  "Ada",
+		SelfURL: "https://rss.example.com/v1/users/7d444840-9dc0-11d1-b245-5ffdce74fad2/feed.rss?token=abc",
+		Updated: published,
+		Items: []outboundItem{{
+			ID:        uuid.MustParse("9b2e7a4c-0f5e-4f7a-8a3e-2c1d5b6e7f80"),
+			Title:     "Fish & <Chips>",
+			URL:       "https://example.com/fish",
+			Summary:   "<p>Short</p>",
+			Content:   "<p>Long</p>",
+			Published: published,
+			Updated:   published,
+		}},
+	}
+}
+
+func TestRenderRSS(t *testing.T) {
+	body, err := renderRSS(testOutboundFeed())
+	if err != nil {
+		t.Fatalf("renderRSS() error = %v", err)
+	}
+
+	// Our own fetcher has to be able to read it back
+	feed := RSSFeed{}
+	err = xml.Unmarshal(body, &feed)
+	if err != nil {
+		t.Fatalf("rendered RSS does not parse: %v\n%s", err, body)
+	}
+	if feed.Channel.Title != "Ada's feed" || len(feed.Channel.Items) != 1 {
+		t.Fatalf("unexpected channel: %+v", feed.Channel)
+	}
+	item := feed.Channel.Items[0]
+	if item.Title != "Fish & <Chips>" || item.Link != "https://example.com/fish" {
+		t.Errorf("unexpected item: %+v", item)
+	}
+	if item.Content != "<p>Long</p>" || item.Description != "<p>Short</p>" {
+		t.Errorf("content = %q, description = %q", item.Content, item.Description)
+	}
+	if item.PubDate != "Fri, 01 Mar 2024 12:00:00 +0000" {
+		t.Errorf("pubDate = %q", item.PubDate)
+	}
+}
+
+func TestRenderAtom(t *testing.T) {
+	body, err := renderAtom(testOutboundFeed())
+	if err != nil {
+		t.Fatalf("renderAtom() error = %v", err)
+	}
+
+	var feed struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Author  string   `xml:"author>name"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Updated string `xml:"updated"`
+			Content struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+	err = xml.Unmarshal(body, &feed)
+	if err != nil {
+		t.Fatalf("rendered Atom does not parse: %v\n%s", err, body)
+	}
+	if feed.ID != "urn:uuid:7d444840-9dc0-11d1-b245-5ffdce74fad2" || feed.Author != "Ada" {
+		t.Errorf("id = %q, author = %q", feed.ID, feed.Author)
+	}
+	if len(feed.Entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(feed.Entries))
+	}
+	entry := feed.Entries[0]
+	if entry.ID != "urn:uuid:9b2e7a4c-0f5e-4f7a-8a3e-2c1d5b6e7f80" || entry.Updated != "2024-03-01T12:00:00Z" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if entry.Content.Type != "html" || entry.Content.Value != "<p>Long</p>" {
+		t.Errorf("content = %+v", entry.Content)
+	}
+}
+
+func TestRenderJSONFeedFallsBackToSummary(t *testing.T) {
+	feed := testOutboundFeed()
+	feed.Items[0].Content = ""
+
+	body, err := renderJSONFeed(feed)
+	if err != nil {
+		t.Fatalf("renderJSONFeed() error = %v", err)
+	}
+
+	var doc struct {
+		Version string `json:"version"`
+		Items   []struct {
+			ID          string `json:"id"`
+			ContentHTML string `json:"content_html"`
+		} `json:"items"`
+	}
+	err = json.Unmarshal(body, &doc)
+	if err != nil {
+		t.Fatalf("rendered JSON Feed does not parse: %v", err)
+	}
+	if doc.Version != "https://jsonfeed.org/version/1.1" || len(doc.Items) != 1 {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+	if doc.Items[0].ContentHTML != "<p>Short</p>" {
+		t.Errorf("content_html = %q, want the summary", doc.Items[0].ContentHTML)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// postCursor marks a position in a list of posts. Clients only ever see it encoded, as an opaque string.
+type postCursor struct {
+	At     time.Time `json:"at"`          // Sort key of the post the cursor points at
+	ID     uuid.UUID `json:"id"`          // Breaks ties between posts with the same sort key
+	Sort   string    `json:"s,omitempty"` // The sort the cursor was made for, a cursor is no use with any other
+	Before bool      `json:"b,omitempty"` // Page back towards the start of the list
+}
+
+func (c postCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePostCursor(s string) (postCursor, error) {
+	c := postCursor{}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	if err == nil && (c.At.IsZero() || c.ID == uuid.Nil) {
+		err = errors.New("incomplete cursor")
+	}
+	return c, err
+}
+
+// setPageLinks points the Link header at the next and previous pages, keeping the other query parameters of r.
+// A nil cursor leaves that link out.
+func setPageLinks(w http.ResponseWriter, r *http.Request, next, prev *postCursor) {
+	links := []string{}
+	for _, link := range []struct {
+		rel    string
+		cursor *postCursor
+	}{
+		{"next", next},
+		{"prev", prev},
+	} {
+		if link.cursor == nil {
+			continue
+		}
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, pageURL(r, "cursor", link.cursor.encode()), link.rel))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+// pageRows turns rows fetched with one row more than limit into a page in list order, and sets its Link header.
+// position returns the cursor pointing at a row.
+func pageRows[T any](w http.ResponseWriter, r *http.Request, rows []T, limit int, cursor *postCursor, position func(T) postCursor) []T {
+	more := len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+	// Paging backwards reads the rows in reverse, so turn them around
+	backwards := cursor != nil && cursor.Before
+	if backwards {
+		slices.Reverse(rows)
+	}
+	if len(rows) == 0 {
+		return rows
+	}
+
+	var next, prev *postCursor
+	// Coming from a later page means there are more rows after this one, and the other way round
+	if more || backwards {
+		c := position(rows[len(rows)-1])
+		next = &c
+	}
+	if (backwards && more) || (!backwards && cursor != nil) {
+		c := position(rows[0])
+		c.Before = true
+		prev = &c
+	}
+	setPageLinks(w, r, next, prev)
+	return rows
+}
+
+// pageURL returns the URL of r with one query parameter replaced.
+func pageURL(r *http.Request, param, value string) string {
+	u := *r.URL
+	query := u.Query()
+	query.Set(param, value)
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPostCursorRoundTrip(t *testing.T) {
+	want := postCursor{At: time.Date(2024, 5, 1, 12, 30, 0, 123, time.UTC), ID: uuid.New(), Before: true}
+
+	got, err := decodePostCursor(want.encode())
+	if err != nil {
+		t.Fatalf("decodePostCursor() error = %v", err)
+	}
+	if !got.At.Equal(want.At) || got.ID != want.ID || got.Before != want.Before {
+		t.Errorf("decodePostCursor() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodePostCursorRejectsGarbage(t *testing.T) {
+	for _, s := range []string{"", "not base64!", "e30", (postCursor{ID: uuid.New()}).encode()} {
+		if _, err := decodePostCursor(s); err == nil {
+			t.Errorf("decodePostCursor(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestSetPageLinks(t *testing.T) {
+	r := httptest.NewRequest("GET", "/v1/posts?limit=5&cursor=old", nil)
+	w := httptest.NewRecorder()
+	next := &postCursor{At: time.Now().UTC(), ID: uuid.New()}
+
+	setPageLinks(w, r, next, nil)
+
+	link := w.Header().Get("Link")
+	want := `</v1/posts?cursor=` + next.encode() + `&limit=5>; rel="next"`
+	if link != want {
+		t.Errorf("Link = %q, want %q", link, want)
+	}
+	if strings.Contains(link, "prev") {
+		t.Errorf("Link = %q, didn't want a prev link", link)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+)
+
+// retentionConfig controls how long old data is kept around.
+type retentionConfig struct {
+	FetchHistory   time.Duration // How long feed_fetches rows are kept
+	PostMaxAgeDays int           // Posts stored longer ago than this are pruned; 0 keeps them forever
+	PostMaxPerFeed int           // Only the most recently stored posts of each feed are kept; 0 keeps them all
+	OrphanedFeeds  time.Duration // Feeds nobody has followed for this long are deleted; 0 keeps them forever
+	WebhookLog     time.Duration // How long finished webhook deliveries are kept
+}
+
+// startPruning deletes expired data straight away and then every interval, until ctx is cancelled.
+func startPruning(ctx context.Context, db *database.Queries, interval time.Duration, cfg retentionConfig) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		pruneOnce(ctx, db, cfg)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pruneOnce runs a single pass of every pruning rule.
+func pruneOnce(ctx context.Context, db *database.Queries, cfg retentionConfig) {
+	now := time.Now().UTC()
+
+	deleted, err := db.DeleteFeedFetchesBefore(ctx, now.Add(-cfg.FetchHistory))
+	if err != nil && ctx.Err() == nil {
+		log.Println("Error pruning fetch history:", err)
+	}
+	if deleted > 0 {
+		log.Printf("Pruned %v fetch history rows", deleted)
+	}
+
+	// Starred posts are never deleted, whatever the policy says
+	deleted, err = db.PrunePosts(ctx, database.PrunePostsParams{
+		DefaultMaxAgeDays: int32(cfg.PostMaxAgeDays),
+		DefaultMaxPosts:   int32(cfg.PostMaxPerFeed),
+		Now:               now,
+	})
+	if err != nil && ctx.Err() == nil {
+		log.Println("Error pruning posts:", err)
+	}
+	if deleted > 0 {
+		log.Printf("Pruned %v posts", deleted)
+	}
+
+	deleted, err = db.DeleteFinishedWebhookDeliveriesBefore(ctx, now.Add(-cfg.WebhookLog))
+	if err != nil && ctx.Err() == nil {
+		log.Println("Error pruning webhook deliveries:", err)
+	}
+	if deleted > 0 {
+		log.Printf("Pruned %v webhook deliveries", deleted)
+	}
+
+	pruneOrphanedFeeds(ctx, db, cfg, now)
+}
+
+// pruneOrphanedFeeds keeps feeds.orphaned_since in step with the follows and deletes feeds
+// that have gone unfollowed for longer than cfg.OrphanedFeeds. Feeds with starred posts are kept.
+func pruneOrphanedFeeds(ctx context.Context, db *database.Queries, cfg retentionConfig, now time.Time) {
+	_, err := db.UnmarkFollowedFeeds(ctx)
+	if err != nil && ctx.Err() == nil {
+		log.Println("Error unmarking followed feeds:", err)
+	}
+	_, err = db.MarkOrphanedFeeds(ctx, now)
+	if err != nil && ctx.Err() == nil {
+		log.Println("Error marking orphaned feeds:", err)
+	}
+
+	if cfg.OrphanedFeeds <= 0 {
+		return
+	}
+	deleted, err := db.DeleteOrphanedFeeds(ctx, now.Add(-cfg.OrphanedFeeds))
+	if err != nil && ctx.Err() == nil {
+		log.Println("Error pruning orphaned feeds:", err)
+	}
+	if deleted > 0 {
+		log.Printf("Pruned %v orphaned feeds", deleted)
+	}
+}
+
+// runPruneCommand implements `rssagg prune [-dry-run]`: it reports how many posts each feed
+// would lose under the current retention policy and, unless this is a dry run, prunes them.
+// Only posts are pruned here, the rest is left to the hourly job.
+func runPruneCommand(ctx context.Context, db *database.Queries, cfg retentionConfig, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("prune", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only report what would be pruned")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	counts, err := db.GetPrunablePostCounts(ctx, database.GetPrunablePostCountsParams{
+		DefaultMaxAgeDays: int32(cfg.PostMaxAgeDays),
+		DefaultMaxPosts:   int32(cfg.PostMaxPerFeed),
+		Now:               time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("counting prunable posts: %w", err)
+	}
+
+	total := int64(0)
+	for _, count := range counts {
+		fmt.Fprintf(out, "%6d  %s (%s)\n", count.Posts, count.Name, count.Url)
+		total += count.Posts
+	}
+	fmt.Fprintf(out, "%6d  posts in %d feeds would be pruned\n", total, len(counts))
+
+	if *dryRun {
+		return nil
+	}
+
+	deleted, err := db.PrunePosts(ctx, database.PrunePostsParams{
+		DefaultMaxAgeDays: int32(cfg.PostMaxAgeDays),
+		DefaultMaxPosts:   int32(cfg.PostMaxPerFeed),
+		Now:               time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("pruning posts: %w", err)
+	}
+	fmt.Fprintf(out, "%6d  posts pruned\n", deleted)
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiterMaxKeys is how many keys a rateLimiter holds before it starts forgetting expired ones.
+const rateLimiterMaxKeys = 1024
+
+// rateLimiter allows one call per key every interval.
+type rateLimiter struct {
+	interval time.Duration
+
+	mu        sync.Mutex
+	last      map[string]time.Time // When each key was last allowed through
+	lastSweep time.Time            // When expired keys were last forgotten
+}
+
+// newRateLimiter creates a rateLimiter allowing one call per key every interval.
+func newRateLimiter(interval time.Duration) *rateLimiter {
+	return &rateLimiter{
+		interval: interval,
+		last:     map[string]time.Time{},
+	}
+}
+
+// rateLimit is one limiter and the key a call counts against.
+type rateLimit struct {
+	limiter *rateLimiter
+	key     string
+}
+
+// allowAll reports whether a call may go ahead now. If not, it also returns how long the caller has to wait.
+// The call is let through only if every limit allows it, and only then counted against all of them,
+// so a call turned away by one limiter doesn't use up its slot in the others.
+// The limiters must be distinct, and always passed in the same order.
+func allowAll(now time.Time, limits ...rateLimit) (bool, time.Duration) {
+	for _, limit := range limits {
+		limit.limiter.mu.Lock()
+		defer limit.limiter.mu.Unlock()
+	}
+
+	for _, limit := range limits {
+		if wait := limit.limiter.wait(limit.key, now); wait > 0 {
+			return false, wait
+		}
+	}
+	for _, limit := range limits {
+		limit.limiter.record(limit.key, now)
+	}
+	return true, 0
+}
+
+// wait returns how long key has to wait before its next call. l.mu must be held.
+func (l *rateLimiter) wait(key string, now time.Time) time.Duration {
+	last, ok := l.last[key]
+	if !ok {
+		return 0
+	}
+	return max(last.Add(l.interval).Sub(now), 0)
+}
+
+// record counts a call by key at now. l.mu must be held.
+func (l *rateLimiter) record(key string, now time.Time) {
+	// Forget keys whose window has passed so the map doesn't grow forever.
+	// At most once per interval, since a full map of recent keys would otherwise be scanned on every call
+	if len(l.last) >= rateLimiterMaxKeys && now.Sub(l.lastSweep) >= l.interval {
+		for k, last := range l.last {
+			if now.Sub(last) >= l.interval {
+				delete(l.last, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	l.last[key] = now
+}
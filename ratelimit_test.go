@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllowsOneCallPerInterval(t *testing.T) {
+	l := newRateLimiter(10 * time.Second)
+	now := time.Date(2024, time.March, 6, 12, 0, 0, 0, time.UTC)
+
+	if ok, _ := allowAll(now, rateLimit{l, "a"}); !ok {
+		t.Fatal("first call was not allowed")
+	}
+	ok, wait := allowAll(now.Add(4*time.Second), rateLimit{l, "a"})
+	if ok || wait != 6*time.Second {
+		t.Errorf("allow() 4s later = %v, %v, expected false, 6s", ok, wait)
+	}
+	if ok, _ := allowAll(now.Add(4*time.Second), rateLimit{l, "b"}); !ok {
+		t.Error("another key was limited")
+	}
+	if ok, _ := allowAll(now.Add(10*time.Second), rateLimit{l, "a"}); !ok {
+		t.Error("call after the interval was not allowed")
+	}
+}
+
+// TestAllowAllOnlyCountsAllowedCalls checks that a call turned away by one limiter keeps its slot in the other
+func TestAllowAllOnlyCountsAllowedCalls(t *testing.T) {
+	users := newRateLimiter(10 * time.Second)
+	feeds := newRateLimiter(time.Minute)
+	now := time.Date(2024, time.March, 6, 12, 0, 0, 0, time.UTC)
+
+	if ok, _ := allowAll(now, rateLimit{users, "ada"}, rateLimit{feeds, "feed"}); !ok {
+		t.Fatal("first call was not allowed")
+	}
+
+	// Grace hits the feed limit, which mustn't count against Grace's own limit
+	ok, wait := allowAll(now.Add(time.Second), rateLimit{users, "grace"}, rateLimit{feeds, "feed"})
+	if ok || wait != 59*time.Second {
+		t.Errorf("allowAll() on a limited feed = %v, %v, expected false, 59s", ok, wait)
+	}
+	if ok, _ := allowAll(now.Add(2*time.Second), rateLimit{users, "grace"}, rateLimit{feeds, "other"}); !ok {
+		t.Error("a call rejected by the feed limiter used up the user's slot")
+	}
+}
+
+func TestRateLimiterForgetsExpiredKeys(t *testing.T) {
+	l := newRateLimiter(time.Second)
+	now := time.Date(2024, time.March, 6, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < rateLimiterMaxKeys; i++ {
+		allowAll(now, rateLimit{l, strconv.Itoa(i)})
+	}
+	allowAll(now.Add(2*time.Second), rateLimit{l, "late"})
+	if len(l.last) != 1 {
+		t.Errorf("%d keys left after the sweep, expected 1", len(l.last))
+	}
+}
@@ -1,49 +1,146 @@
 package main
 
 import (
+	"context"      // Used for cancelling in-flight requests
 	"encoding/xml" // Used for parsing XML data
+	"fmt"          // Used for formatting errors
 	"io"           // Provides utilities for reading data
 	"net/http"     // Handles HTTP requests
+	"strings"      // Used for trimming links
 	"time"         // Used for setting timeouts
 )
 
 // RSSFeed struct represents the structure of an RSS feed.
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`       // Title of the feed
-		Link        string    `xml:"link"`        // Link to the website
-		Description string    `xml:"description"` // Description of the feed
-		Language    string    `xml:"language"`    // Language of the feed
-		Items       []RSSItem `xml:"item"`        // List of RSS items (posts)
+		Title           string    `xml:"title"`                                                        // Title of the feed
+		Links           []string  `xml:"link"`                                                         // Link to the website, next to any empty atom:link elements
+		Description     string    `xml:"description"`                                                  // Description of the feed
+		Language        string    `xml:"language"`                                                     // Language of the feed
+		TTL             string    `xml:"ttl"`                                                          // Minutes the channel may be cached
+		UpdatePeriod    string    `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`    // sy:updatePeriod (hourly, daily, ...)
+		UpdateFrequency string    `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"` // sy:updateFrequency, updates per period
+		SkipHours       []string  `xml:"skipHours>hour"`                                               // Hours (GMT) the feed should not be read
+		SkipDays        []string  `xml:"skipDays>day"`                                                 // Days the feed should not be read
+		Items           []RSSItem `xml:"item"`                                                         // List of RSS items (posts)
 	} `xml:"channel"` // XML tag that matches the RSS structure
 }
 
 // RSSItem struct represents an individual item (post) in an RSS feed.
 type RSSItem struct {
-	Title       string `xml:"title"`       // Title of the post
-	Link        string `xml:"link"`        // URL of the post
-	Description string `xml:"description"` // Short summary of the post
-	PubDate     string `xml:"pubDate"`     // Published date in string format
+	Title       string   `xml:"title"`                                               // Title of the post
+	Link        string   `xml:"link"`                                                // URL of the post
+	Description string   `xml:"description"`                                         // Short summary of the post
+	PubDate     string   `xml:"pubDate"`                                             // Published date in string format
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`    // content:encoded, the full article when the feed carries it
+	OrigLink    string   `xml:"http://rssnamespace.org/feedburner/ext/1.0 origLink"` // feedburner:origLink, the article behind a FeedBurner redirect
+	Author      string   `xml:"author"`                                              // Author's email, often with the name in brackets
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`            // dc:creator, the author's name
+	Categories  []string `xml:"category"`                                            // Tags of the post
+}
+
+// siteURL returns the channel's link to its website.
+func (feed RSSFeed) siteURL() string {
+	// <atom:link rel="self"> matches the same tag but only has attributes
+	for _, link := range feed.Channel.Links {
+		if link = strings.TrimSpace(link); link != "" {
+			return link
+		}
+	}
+	return ""
+}
+
+// fetchMeta carries the parts of the HTTP response that matter after the body has been parsed.
+type fetchMeta struct {
+	StatusCode int           // HTTP status code returned by the server
+	Header     http.Header   // Response headers (Cache-Control, Retry-After, ...)
+	Bytes      int64         // Size of the response body we read
+	Duration   time.Duration // Time from sending the request to the end of the body
+}
+
+// author returns the item's author, preferring dc:creator which holds a plain name.
+func (item RSSItem) author() string {
+	if creator := strings.TrimSpace(item.Creator); creator != "" {
+		return creator
+	}
+	return strings.TrimSpace(item.Author)
+}
+
+// categorySeparator joins an item's categories for CreatePosts, which splits them on chr(31) again.
+const categorySeparator = "\x1f"
+
+// categories returns the item's categories, trimmed and without empty or repeated ones.
+func (item RSSItem) categories() []string {
+	categories := []string{}
+	seen := map[string]bool{}
+	for _, category := range item.Categories {
+		category = strings.TrimSpace(strings.ReplaceAll(category, categorySeparator, ""))
+		if category == "" || seen[category] {
+			continue
+		}
+		seen[category] = true
+		categories = append(categories, category)
+	}
+	return categories
+}
+
+// pubDateFormats lists the date layouts seen in the wild for <pubDate>.
+var pubDateFormats = []string{
+	time.RFC1123Z, time.RFC1123, time.RFC3339, "Mon, 2 Jan 2006 15:04:05 MST",
+}
+
+// parsePubDate parses an item's publication date using the known formats.
+func parsePubDate(value string) (time.Time, error) {
+	var err error
+	for _, format := range pubDateFormats {
+		var t time.Time
+		t, err = time.Parse(format, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// feedClient is shared by all fetches so connections to the same host are reused.
+// If the server takes more than 10 seconds to respond, the request times out.
+var feedClient = &http.Client{
+	Timeout: 10 * time.Second,
 }
 
 // urlTofeed fetches and parses an RSS feed from a given URL.
-func urlTofeed(url string) (RSSFeed, error) {
-	// Create an HTTP client with a timeout of 10 seconds and if the server takes more time to respond more than 10 seconds, it will timeout
-	httpClient := &http.Client{
-		Timeout: 10 * time.Second,
+func urlTofeed(ctx context.Context, url string) (RSSFeed, fetchMeta, error) {
+	// Build a GET request that is abandoned as soon as ctx is cancelled
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return RSSFeed{}, fetchMeta{}, err
 	}
 
-	// Send a GET request to the RSS feed URL
-	resp, err := httpClient.Get(url)
+	// Send the request to the RSS feed URL
+	start := time.Now()
+	resp, err := feedClient.Do(req)
 	if err != nil {
-		return RSSFeed{}, err // Return an empty RSSFeed and the error if the request fails
+		return RSSFeed{}, fetchMeta{Duration: time.Since(start)}, err // Return an empty RSSFeed and the error if the request fails
 	}
 	defer resp.Body.Close() // Ensure the response body is closed after function execution
 
+	meta := fetchMeta{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+	}
+
+	// Anything other than a 2xx response has no feed to parse, but the headers may still tell us when to come back
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		meta.Duration = time.Since(start)
+		return RSSFeed{}, meta, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
 	// Read the response body
 	data, err := io.ReadAll(resp.Body)
+	meta.Bytes = int64(len(data))
+	meta.Duration = time.Since(start)
 	if err != nil {
-		return RSSFeed{}, err // Return an error if reading fails
+		return RSSFeed{}, meta, err // Return an error if reading fails
 	}
 
 	// Initialize an empty RSSFeed struct
@@ -52,9 +149,9 @@ func urlTofeed(url string) (RSSFeed, error) {
 	// Unmarshal (convert) XML data into the RSSFeed struct
 	err = xml.Unmarshal(data, &rssFeed)
 	if err != nil {
-		return RSSFeed{}, err // Return an error if parsing XML fails
+		return RSSFeed{}, meta, err // Return an error if parsing XML fails
 	}
 
 	// Return the parsed RSS feed
-	return rssFeed, nil
+	return rssFeed, meta, nil
 }
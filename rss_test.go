@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+// TestRSSItemAuthorAndCategories checks what the scraper stores for filter rules to match on
+func TestRSSItemAuthorAndCategories(t *testing.T) {
+	body := `<rss xmlns:dc="http://purl.org/dc/elements/1.1/"><channel>
+<item><title>One</title><author>ada@example.com (Ada)</author><dc:creator> Ada Lovelace </dc:creator>
+<category>Jobs</category><category> Sponsored </category><category>Jobs</category><category> </category></item>
+<item><title>Two</title><author>grace@example.com (Grace)</author></item>
+<item><title>Three</title></item>
+</channel></rss>`
+
+	feed := RSSFeed{}
+	err := xml.Unmarshal([]byte(body), &feed)
+	if err != nil {
+		t.Fatalf("xml.Unmarshal() error = %v", err)
+	}
+
+	tests := []struct {
+		author     string
+		categories []string
+	}{
+		{"Ada Lovelace", []string{"Jobs", "Sponsored"}},
+		{"grace@example.com (Grace)", []string{}},
+		{"", []string{}},
+	}
+	for i, tt := range tests {
+		item := feed.Channel.Items[i]
+		if got := item.author(); got != tt.author {
+			t.Errorf("item %d author() = %q, expected %q", i, got, tt.author)
+		}
+		if got := item.categories(); !reflect.DeepEqual(got, tt.categories) {
+			t.Errorf("item %d categories() = %q, expected %q", i, got, tt.categories)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minFetchInterval is the lowest FETCH_MIN_INTERVAL we accept, so a typo can't have us hammering every feed.
+const minFetchInterval = time.Minute
+
+// scheduleConfig bounds how often a single feed may be polled.
+type scheduleConfig struct {
+	MinInterval time.Duration // Never poll a feed more often than this
+	MaxInterval time.Duration // Never leave a feed unpolled for longer than this
+}
+
+// nextFetchAt works out when a feed should be fetched again.
+//
+// The interval starts from how often the feed has actually been posting and is
+// stretched by whatever the publisher asked for (<ttl>, sy:updatePeriod and
+// Cache-Control), then clamped to the configured bounds. Retry-After pushes the
+// result further out, and skipHours/skipDays move it out of blocked windows.
+func nextFetchAt(now time.Time, cfg scheduleConfig, feed RSSFeed, meta fetchMeta) time.Time {
+	interval := postingInterval(feed.Channel.Items)
+
+	if ttl, err := strconv.Atoi(strings.TrimSpace(feed.Channel.TTL)); err == nil && ttl > 0 {
+		interval = max(interval, time.Duration(ttl)*time.Minute)
+	}
+	interval = max(interval, syndicationInterval(feed.Channel.UpdatePeriod, feed.Channel.UpdateFrequency))
+	interval = max(interval, cacheMaxAge(meta.Header))
+	interval = min(max(interval, cfg.MinInterval), cfg.MaxInterval)
+
+	next := now.Add(interval)
+
+	// The server told us explicitly when to come back, but don't let it park the feed forever
+	if retryAt, ok := retryAfter(meta.Header, now); ok && retryAt.After(next) {
+		next = retryAt
+		if limit := now.Add(cfg.MaxInterval); next.After(limit) {
+			next = limit
+		}
+	}
+
+	return skipBlockedWindows(next, feed.Channel.SkipHours, feed.Channel.SkipDays)
+}
+
+// postingInterval returns the average gap between the dated items of a feed, or 0 if it can't tell.
+func postingInterval(items []RSSItem) time.Duration {
+	dates := make([]time.Time, 0, len(items))
+	for _, item := range items {
+		pubAt, err := parsePubDate(item.PubDate)
+		if err == nil {
+			dates = append(dates, pubAt)
+		}
+	}
+	if len(dates) < 2 {
+		return 0
+	}
+
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates[len(dates)-1].Sub(dates[0]) / time.Duration(len(dates)-1)
+}
+
+// syndicationInterval converts sy:updatePeriod and sy:updateFrequency into a duration.
+func syndicationInterval(period, frequency string) time.Duration {
+	periods := map[string]time.Duration{
+		"hourly":  time.Hour,
+		"daily":   24 * time.Hour,
+		"weekly":  7 * 24 * time.Hour,
+		"monthly": 30 * 24 * time.Hour,
+		"yearly":  365 * 24 * time.Hour,
+	}
+
+	base, ok := periods[strings.ToLower(strings.TrimSpace(period))]
+	if !ok {
+		return 0
+	}
+
+	// The frequency defaults to 1 update per period when it is missing or invalid
+	freq, err := strconv.Atoi(strings.TrimSpace(frequency))
+	if err != nil || freq < 1 {
+		freq = 1
+	}
+	return base / time.Duration(freq)
+}
+
+// cacheMaxAge returns the max-age directive of a Cache-Control header, or 0 if there is none.
+func cacheMaxAge(header http.Header) time.Duration {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(directive), "=")
+		if !found || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+		if err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return 0
+}
+
+// retryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func retryAfter(header http.Header, now time.Time) (time.Time, bool) {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return now.Add(time.Duration(seconds) * time.Second), seconds >= 0
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return at, true
+	}
+	return time.Time{}, false
+}
+
+// skipBlockedWindows moves t forward, an hour at a time, until it is outside the feed's skipHours and skipDays.
+func skipBlockedWindows(t time.Time, skipHours, skipDays []string) time.Time {
+	hours := map[int]bool{}
+	for _, h := range skipHours {
+		hour, err := strconv.Atoi(strings.TrimSpace(h))
+		if err == nil && hour >= 0 && hour < 24 {
+			hours[hour] = true
+		}
+	}
+	days := map[string]bool{}
+	for _, d := range skipDays {
+		days[strings.ToLower(strings.TrimSpace(d))] = true
+	}
+
+	// The RSS spec expresses both lists in GMT; a week of hours is enough to find a free slot if there is one
+	t = t.UTC()
+	for i := 0; i < 7*24; i++ {
+		if !hours[t.Hour()] && !days[strings.ToLower(t.Weekday().String())] {
+			return t
+		}
+		t = t.Truncate(time.Hour).Add(time.Hour)
+	}
+	return t
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestNextFetchAt checks that each scheduling hint moves the next fetch as expected
+func TestNextFetchAt(t *testing.T) {
+	cfg := scheduleConfig{MinInterval: 10 * time.Minute, MaxInterval: 24 * time.Hour}
+	now := time.Date(2024, time.March, 6, 12, 0, 0, 0, time.UTC) // A Wednesday
+
+	hourlyItems := []RSSItem{
+		{PubDate: "Wed, 06 Mar 2024 09:00:00 +0000"},
+		{PubDate: "Wed, 06 Mar 2024 10:00:00 +0000"},
+		{PubDate: "Wed, 06 Mar 2024 11:00:00 +0000"},
+	}
+
+	tests := []struct {
+		name   string
+		feed   func(*RSSFeed)
+		header http.Header
+		want   time.Time
+	}{
+		{
+			name: "no hints uses the minimum interval",
+			want: now.Add(10 * time.Minute),
+		},
+		{
+			name: "observed posting frequency",
+			feed: func(f *RSSFeed) { f.Channel.Items = hourlyItems },
+			want: now.Add(time.Hour),
+		},
+		{
+			name: "ttl stretches the interval",
+			feed: func(f *RSSFeed) { f.Channel.Items = hourlyItems; f.Channel.TTL = "180" },
+			want: now.Add(3 * time.Hour),
+		},
+		{
+			name: "sy:updatePeriod with frequency",
+			feed: func(f *RSSFeed) { f.Channel.UpdatePeriod = "daily"; f.Channel.UpdateFrequency = "4" },
+			want: now.Add(6 * time.Hour),
+		},
+		{
+			name:   "cache-control max-age",
+			header: http.Header{"Cache-Control": {"public, max-age=7200"}},
+			want:   now.Add(2 * time.Hour),
+		},
+		{
+			name: "clamped to the maximum interval",
+			feed: func(f *RSSFeed) { f.Channel.UpdatePeriod = "weekly" },
+			want: now.Add(24 * time.Hour),
+		},
+		{
+			name:   "retry-after in seconds",
+			header: http.Header{"Retry-After": {"3600"}},
+			want:   now.Add(time.Hour),
+		},
+		{
+			name:   "retry-after as an http date",
+			header: http.Header{"Retry-After": {"Wed, 06 Mar 2024 15:00:00 GMT"}},
+			want:   now.Add(3 * time.Hour),
+		},
+		{
+			name: "skip hours",
+			feed: func(f *RSSFeed) { f.Channel.SkipHours = []string{"12", "13"} },
+			want: time.Date(2024, time.March, 6, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "skip days",
+			feed: func(f *RSSFeed) { f.Channel.SkipDays = []string{"Wednesday"} },
+			want: time.Date(2024, time.March, 7, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := RSSFeed{}
+			if tt.feed != nil {
+				tt.feed(&feed)
+			}
+
+			got := nextFetchAt(now, cfg, feed, fetchMeta{Header: tt.header})
+			if !got.Equal(tt.want) {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestRetryAt checks the backoff of failed jobs and when a server's Retry-After overrides it
+func TestRetryAt(t *testing.T) {
+	now := time.Date(2024, time.March, 6, 12, 0, 0, 0, time.UTC)
+	retryIn := func(value string) http.Header {
+		return http.Header{"Retry-After": {value}}
+	}
+
+	tests := []struct {
+		name     string
+		attempts int
+		meta     fetchMeta
+		want     time.Time
+	}{
+		{"first failure waits the base delay", 1, fetchMeta{}, now.Add(time.Minute)},
+		{"delay doubles per attempt", 4, fetchMeta{}, now.Add(8 * time.Minute)},
+		{"delay is capped", 30, fetchMeta{}, now.Add(6 * time.Hour)},
+		{"later Retry-After on 429 wins", 1, fetchMeta{StatusCode: http.StatusTooManyRequests, Header: retryIn("600")}, now.Add(10 * time.Minute)},
+		{"Retry-After date on 503", 1, fetchMeta{StatusCode: http.StatusServiceUnavailable, Header: retryIn("Wed, 06 Mar 2024 13:00:00 GMT")}, now.Add(time.Hour)},
+		{"earlier Retry-After is ignored", 4, fetchMeta{StatusCode: http.StatusTooManyRequests, Header: retryIn("30")}, now.Add(8 * time.Minute)},
+		{"Retry-After only counts on 429 and 503", 1, fetchMeta{StatusCode: http.StatusInternalServerError, Header: retryIn("600")}, now.Add(time.Minute)},
+	}
+
+	for _, tt := range tests {
+		got := retryAt(now, tt.attempts, tt.meta)
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: retryAt() = %v, expected %v", tt.name, got, tt.want)
+		}
+	}
+}
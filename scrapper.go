@@ -3,7 +3,10 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -12,102 +15,379 @@ import (
 	"github.com/google/uuid"                         // Importing UUID for generating unique IDs
 )
 
-// startScrapping initiates the RSS feed scraping process at regular intervals.
-func startScrapping(db *database.Queries, concurrency int, timeBetweenRequest time.Duration) {
-	log.Printf("Scraping on %v goroutines every %s duration", concurrency, timeBetweenRequest)
-
-	// Ticker triggers the scraping process at the specified time interval
-	ticker := time.NewTicker(timeBetweenRequest)
-	for ; ; <-ticker.C { // Infinite loop to keep running the scraper
-		feeds, err := db.GetNextFeedsToFetch(
-			context.Background(),
-			int32(concurrency), // Fetch a batch of feeds based on concurrency level
-		)
+// fetchTimeout bounds how long a worker may spend on a single feed, including the database writes.
+const fetchTimeout = 30 * time.Second
+
+// Failed jobs are retried after retryBaseDelay, doubling on every attempt up to retryMaxDelay.
+const (
+	retryBaseDelay = time.Minute
+	retryMaxDelay  = 6 * time.Hour
+)
+
+// scraper keeps a fixed pool of workers busy with fetch jobs from the scrape_jobs table.
+// Jobs are leased in the database before they are handed out, so several instances can share the work.
+type scraper struct {
+	Conn          *sql.DB // Used to group each feed's writes into one transaction
+	DB            *database.Queries
+	Concurrency   int            // Number of feeds fetched at the same time
+	PollInterval  time.Duration  // How long the dispatcher waits when no job is due
+	LeaseDuration time.Duration  // How long a claimed job stays reserved for this instance
+	MaxAttempts   int            // Failed attempts before a job is dead-lettered
+	Schedule      scheduleConfig // Bounds for the adaptive per-feed schedule
+}
+
+// startScrapping runs the dispatcher and its workers until ctx is cancelled.
+// Once cancelled no new jobs are handed out, and it returns after the in-flight fetches have finished.
+func (s *scraper) startScrapping(ctx context.Context) {
+	log.Printf("Scraping on %v workers, polling every %s when idle", s.Concurrency, s.PollInterval)
+
+	jobs := make(chan database.ScrapeJob)
+
+	// Each worker takes the next job as soon as it is free, so one slow feed only ties up its own worker
+	wg := &sync.WaitGroup{}
+	for i := 0; i < s.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				// In-flight fetches are allowed to finish on shutdown, but never for longer than fetchTimeout
+				jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+				s.runJob(jobCtx, job)
+				cancel()
+			}
+		}()
+	}
+
+	// Queueing due feeds scans every feed, so it runs on its own clock instead of on every claim
+	s.enqueueDueFeeds(ctx)
+	enqueuerDone := make(chan struct{})
+	go func() {
+		defer close(enqueuerDone)
+		ticker := time.NewTicker(s.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.enqueueDueFeeds(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	s.dispatch(ctx, jobs)
+	close(jobs)
+	wg.Wait()
+	<-enqueuerDone
+	log.Println("Scraper stopped")
+}
+
+// enqueueDueFeeds gives every feed that is due a job, unless it already has one waiting, running or dead-lettered.
+func (s *scraper) enqueueDueFeeds(ctx context.Context) {
+	_, err := s.DB.EnqueueDueFeeds(ctx, time.Now().UTC())
+	if err != nil && ctx.Err() == nil {
+		log.Println("Error enqueueing due feeds:", err)
+	}
+}
+
+// dispatch claims jobs and hands them to the workers until ctx is cancelled.
+func (s *scraper) dispatch(ctx context.Context, jobs chan<- database.ScrapeJob) {
+	for {
+		now := time.Now().UTC()
+
+		// Claiming leases the jobs in one statement, so another instance polling at the same time skips them
+		claimed, err := s.DB.ClaimScrapeJobs(ctx, database.ClaimScrapeJobsParams{
+			LeaseUntil: now.Add(s.LeaseDuration),
+			Now:        now,
+			BatchSize:  int32(s.Concurrency),
+		})
+		if err != nil && ctx.Err() == nil {
+			log.Println("Error claiming scrape jobs:", err)
+		}
+
+		for _, job := range claimed {
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				// Unstarted jobs keep their lease and are picked up again once it runs out
+				return
+			}
+		}
+
+		// Nothing was due, so wait before asking the database again
+		if len(claimed) == 0 {
+			select {
+			case <-time.After(s.PollInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// runJob scrapes the feed behind a job and then completes it, schedules a retry or dead-letters it.
+func (s *scraper) runJob(ctx context.Context, job database.ScrapeJob) {
+	feed, err := s.DB.GetFeedByID(ctx, job.FeedID)
+	if err != nil {
+		// The job is deleted along with its feed, so there is nothing left to do
+		log.Println("Error getting feed for scrape job:", err)
+		return
+	}
+
+	s.scrapeJobFeed(ctx, job, feed)
+}
+
+// claimFeed leases the job of a single feed, creating it if the feed isn't queued, so it can be fetched
+// outside the dispatcher without a worker fetching it at the same time.
+// It returns sql.ErrNoRows while a worker holds the lease.
+func (s *scraper) claimFeed(ctx context.Context, feedID uuid.UUID) (database.ScrapeJob, error) {
+	now := time.Now().UTC()
+	return s.DB.ClaimScrapeJobForFeed(ctx, database.ClaimScrapeJobForFeedParams{
+		Now:        now,
+		FeedID:     feedID,
+		LeaseUntil: now.Add(s.LeaseDuration),
+	})
+}
+
+// scrapeJobFeed scrapes the feed of a claimed job and then completes the job, schedules a retry or dead-letters it.
+func (s *scraper) scrapeJobFeed(ctx context.Context, job database.ScrapeJob, feed database.Feed) (scrapeResult, error) {
+	result, fetchErr := s.scrapeFeed(ctx, feed)
+	if fetchErr == nil {
+		err := s.DB.CompleteScrapeJob(ctx, job.ID)
 		if err != nil {
-			log.Println(err)
-			continue // Skip this iteration if there's an error
+			log.Println("Error completing scrape job:", err)
 		}
+		return result, nil
+	}
+	s.failJob(ctx, job, feed, result, fetchErr)
+	return result, fetchErr
+}
+
+// failJob schedules a retry of a failed job, or dead-letters it once it is out of attempts.
+func (s *scraper) failJob(ctx context.Context, job database.ScrapeJob, feed database.Feed, result scrapeResult, err error) {
+	lastError := sql.NullString{String: err.Error(), Valid: true}
+	lastStatusCode := sql.NullInt32{Int32: int32(result.Meta.StatusCode), Valid: result.Meta.StatusCode != 0}
 
-		// Waitgroup ensures in hadnling multiple tasks and manage multiple goroutines in batch systems.
-		wg := &sync.WaitGroup{}
-		for _, feed := range feeds {
-			wg.Add(1)                   // Increase counter for each feed being processed
-			go scrapeFeed(db, wg, feed) // Launch a goroutine to scrape the feed concurrently
+	// Out of attempts: park the job until an admin retries it
+	if int(job.Attempts) >= s.MaxAttempts {
+		log.Printf("Dead-lettering feed %s after %d attempts: %v", feed.Url, job.Attempts, err)
+		err = s.DB.DeadLetterScrapeJob(ctx, database.DeadLetterScrapeJobParams{
+			ID:             job.ID,
+			LastError:      lastError,
+			LastStatusCode: lastStatusCode,
+		})
+		if err != nil {
+			log.Println("Error dead-lettering scrape job:", err)
 		}
-		wg.Wait() // Wait until all goroutines complete before proceeding
+		return
+	}
+
+	err = s.DB.RetryScrapeJobLater(ctx, database.RetryScrapeJobLaterParams{
+		ID:             job.ID,
+		RunAt:          retryAt(time.Now().UTC(), int(job.Attempts), result.Meta),
+		LastError:      lastError,
+		LastStatusCode: lastStatusCode,
+	})
+	if err != nil {
+		log.Println("Error rescheduling scrape job:", err)
 	}
 }
 
-// scrapeFeed fetches and processes an individual RSS feed.
-func scrapeFeed(db *database.Queries, wg *sync.WaitGroup, feed database.Feed) {
-	defer wg.Done() // Decrement the WaitGroup counter when the function completes
+// retryAt returns when a failed job should run again: exponential backoff,
+// or later if a 429 or 503 response carried a Retry-After header.
+func retryAt(now time.Time, attempts int, meta fetchMeta) time.Time {
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	at := now.Add(min(delay, retryMaxDelay))
+
+	if meta.StatusCode == http.StatusTooManyRequests || meta.StatusCode == http.StatusServiceUnavailable {
+		if serverAt, ok := retryAfter(meta.Header, now); ok && serverAt.After(at) {
+			at = serverAt
+		}
+	}
+	return at
+}
+
+// scrapeResult summarises one fetch of a feed.
+type scrapeResult struct {
+	Meta      fetchMeta       // HTTP response details, as far as the request got
+	ItemsSeen int             // Items in the fetched document
+	NewPosts  []database.Post // Posts stored by this fetch that we didn't have before
+}
+
+// upstreamError is a failure of the feed itself (the request, the response or the document in it)
+// rather than of our own database.
+type upstreamError struct {
+	err error
+}
+
+func (e upstreamError) Error() string { return e.err.Error() }
+func (e upstreamError) Unwrap() error { return e.err }
+
+// scrapeFeed fetches and processes an individual RSS feed, and records the attempt in the feed's fetch history.
+// A returned error means the fetch failed and should be retried; the result is filled in as far as the fetch got.
+func (s *scraper) scrapeFeed(ctx context.Context, feed database.Feed) (scrapeResult, error) {
+	fetchedAt := time.Now().UTC()
+	result, err := s.fetchAndStore(ctx, feed)
+	s.recordFetch(ctx, feed, fetchedAt, result, err)
+	return result, err
+}
+
+// recordFetch adds one row to the fetch history of a feed.
+func (s *scraper) recordFetch(ctx context.Context, feed database.Feed, fetchedAt time.Time, result scrapeResult, fetchErr error) {
+	// The fetch may have used up its deadline, but the history row should still be written
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	errMsg := sql.NullString{}
+	if fetchErr != nil {
+		errMsg = sql.NullString{String: fetchErr.Error(), Valid: true}
+	}
+
+	err := s.DB.CreateFeedFetch(ctx, database.CreateFeedFetchParams{
+		ID:            uuid.New(),
+		FeedID:        feed.ID,
+		FetchedAt:     fetchedAt,
+		StatusCode:    sql.NullInt32{Int32: int32(result.Meta.StatusCode), Valid: result.Meta.StatusCode != 0},
+		Bytes:         result.Meta.Bytes,
+		DurationMs:    int32(result.Meta.Duration.Milliseconds()),
+		ItemsSeen:     int32(result.ItemsSeen),
+		ItemsInserted: int32(len(result.NewPosts)),
+		Error:         errMsg,
+	})
+	if err != nil {
+		log.Println("Error recording feed fetch:", err)
+	}
+}
+
+// fetchAndStore does the actual work of scrapeFeed: fetch, parse, store new posts and schedule the next fetch.
+func (s *scraper) fetchAndStore(ctx context.Context, feed database.Feed) (scrapeResult, error) {
+	result := scrapeResult{}
 
 	// Validate that the feed URL is not empty
 	if feed.Url == "" {
-		log.Println("Skipping feed: Empty URL")
-		return
+		return result, upstreamError{errors.New("feed has an empty URL")}
 	}
 
-	// Mark the feed as fetched in the database to prevent duplicate processing
-	_, err := db.MarkFeedAsFetched(context.Background(), feed.ID)
+	// Record the fetch time; the job lease already keeps other workers away from this feed
+	_, err := s.DB.MarkFeedAsFetched(ctx, feed.ID)
 	if err != nil {
-		log.Println("Error marking feed as fetched:", err)
-		return
+		return result, fmt.Errorf("marking feed as fetched: %w", err)
 	} // Parses in terms of it converts the XML file into the structres that we can understand
 
 	// Fetch and parse the RSS feed
-	rssFeed, err := urlTofeed(feed.Url)
+	rssFeed, meta, err := urlTofeed(ctx, feed.Url)
+	result.Meta = meta
 	if err != nil {
-		log.Println("Error parsing feed:", err)
-		return
+		return result, upstreamError{err} // Failed fetches are retried by the job queue
 	}
+	result.ItemsSeen = len(rssFeed.Channel.Items)
 
+	// Collect the items column by column so they can be inserted in a single statement
+	params := database.CreatePostsParams{
+		Now:    time.Now().UTC(),
+		Name:   feed.Name,
+		FeedID: feed.ID, // Associating the posts with their feed
+	}
+	seen := map[string]bool{}
 	for _, item := range rssFeed.Channel.Items { // Iterate over all items (posts) in the feed
-		// Handle cases where the description might be null
-		description := sql.NullString{}
-		if item.Description != "" {
-			description.String = item.Description
-			description.Valid = true
+		// Items without a link can't be stored, and a feed repeating an article would only conflict with itself
+		if item.Link == "" {
+			continue
 		}
-
-		// Parse the publication date of the post
-		var pubAt time.Time
-		dateFormats := []string{
-			time.RFC1123Z, time.RFC1123, time.RFC3339, "Mon, 2 Jan 2006 15:04:05 MST",
+		// FeedBurner links all point at its redirector, the original link says which article it is.
+		// A canonical link in the full text beats both, since the publisher put it there
+		canonicalURL := item.Link
+		if item.OrigLink != "" {
+			canonicalURL = item.OrigLink
 		}
-
-		// Try parsing the date using multiple formats
-		for _, format := range dateFormats {
-			pubAt, err = time.Parse(format, item.PubDate)
-			if err == nil {
-				break // Exit loop if parsing is successful
-			}
+		if link := canonicalLink(item.Content); link != "" {
+			canonicalURL = link
+		}
+		canonicalURL = canonicalizeURL(canonicalURL)
+		if seen[canonicalURL] {
+			continue
 		}
+		seen[canonicalURL] = true
+
+		// Parse the publication date of the post, trying each of the known formats
+		pubAt, err := parsePubDate(item.PubDate)
 
 		// If parsing fails, default to the current time
 		if err != nil {
 			log.Println("Error parsing pub date:", err, "Raw Date:", item.PubDate)
-			pubAt = time.Now().UTC()
-
+			pubAt = params.Now
 		}
 
-		// Insert the parsed feed item into the database
-		_, err = db.CreatePost(context.Background(), database.CreatePostParams{
-			ID:          uuid.New(), // Generate a unique ID for the post
-			CreatedAt:   time.Now().UTC(),
-			UpdatedAt:   time.Now().UTC(),
-			Title:       item.Title,
-			Description: description,
-			PublishedAt: pubAt,
-			Url:         item.Link,
-			FeedID:      feed.ID, // Associating the post with its feed
+		params.Ids = append(params.Ids, uuid.New()) // Generate a unique ID for the post
+		params.Titles = append(params.Titles, item.Title)
+		params.Descriptions = append(params.Descriptions, item.Description) // Empty descriptions are stored as NULL
+		params.PublishedAts = append(params.PublishedAts, pubAt)
+		params.Urls = append(params.Urls, item.Link)
+		params.CanonicalUrls = append(params.CanonicalUrls, canonicalURL)
+		params.Contents = append(params.Contents, item.Content) // Only some feeds carry the full article
+		params.Authors = append(params.Authors, item.author())
+		// An array of arrays can't be unnested per row, so each item's categories travel as one separated string
+		params.Categories = append(params.Categories, strings.Join(item.categories(), categorySeparator))
+	}
+
+	// Store the new posts and the next fetch time together, so a failed write leaves the feed due for a retry
+	tx, err := s.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := s.DB.WithTx(tx)
+
+	// Keep the feed's website up to date, an empty link leaves whatever we had.
+	// The feed is updated before the posts go in, since storing them holds the seq lock until commit.
+	if siteURL := rssFeed.siteURL(); siteURL != "" {
+		err = qtx.SetFeedSiteURL(ctx, database.SetFeedSiteURLParams{
+			ID:      feed.ID,
+			SiteUrl: sql.NullString{String: siteURL, Valid: true},
 		})
 		if err != nil {
-			// Skip duplicate entries to avoid inserting the same post multiple times
-			if strings.Contains(err.Error(), "duplicate key") {
-				continue
-			}
-			log.Println("Error creating post:", err)
+			return result, fmt.Errorf("updating site URL: %w", err)
+		}
+	}
+
+	// Work out when this feed is due again
+	err = qtx.SetFeedNextFetchAt(ctx, database.SetFeedNextFetchAtParams{
+		ID:          feed.ID,
+		NextFetchAt: sql.NullTime{Time: nextFetchAt(time.Now().UTC(), s.Schedule, rssFeed, meta), Valid: true},
+	})
+	if err != nil {
+		return result, fmt.Errorf("scheduling next fetch: %w", err)
+	}
+
+	// Posts we already have are skipped by ON CONFLICT, so only the new ones come back.
+	// The same statement queues their webhook deliveries, so no new post is ever missed.
+	// They only go into the result once committed, a rolled back fetch stored nothing.
+	posts, err := createPostsInSeqOrder(ctx, qtx, params)
+	if err != nil {
+		return result, fmt.Errorf("creating posts: %w", err)
+	}
+
+	// Tell the post streams of every instance; Postgres only delivers this once the transaction commits
+	if len(posts) > 0 {
+		payload, err := newPostsPayload(feed.ID, posts)
+		if err != nil {
+			return result, fmt.Errorf("encoding new posts notification: %w", err)
 		}
+		err = qtx.NotifyNewPosts(ctx, payload)
+		if err != nil {
+			return result, fmt.Errorf("notifying new posts: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return result, fmt.Errorf("committing posts: %w", err)
 	}
+	result.NewPosts = posts
+
+	log.Printf("Feed %s collected, %v items seen, %v new", feed.Name, result.ItemsSeen, len(result.NewPosts))
+	return result, nil
 }
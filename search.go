@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// buildTSQuery turns a search box query into to_tsquery syntax, so users never hit a tsquery syntax error.
+//
+// Words are ANDed together. "Quoted words" must appear next to each other, a trailing * matches
+// any word starting with the prefix, a leading - excludes a word and OR between two terms accepts either.
+// Each word is passed to Postgres quoted, so it is split and stemmed the same way the posts were:
+// "e-mail", "don't" or "node.js" match what to_tsvector stored for them. An empty result means there was nothing to search for.
+func buildTSQuery(q string) string {
+	terms := []string{}
+	joinOr := false
+
+	addTerm := func(term string) {
+		if term == "" {
+			return
+		}
+		if len(terms) > 0 {
+			if joinOr {
+				terms = append(terms, "|")
+			} else {
+				terms = append(terms, "&")
+			}
+		}
+		terms = append(terms, term)
+		joinOr = false
+	}
+
+	for len(q) > 0 {
+		q = strings.TrimLeftFunc(q, unicode.IsSpace)
+		if q == "" {
+			break
+		}
+
+		negate := false
+		if q[0] == '-' {
+			negate = true
+			q = q[1:]
+		}
+
+		var term string
+		if strings.HasPrefix(q, `"`) {
+			// A phrase runs to the closing quote, or to the end if there is none
+			phrase, rest, _ := strings.Cut(q[1:], `"`)
+			q = rest
+			words := []string{}
+			for _, word := range strings.Fields(phrase) {
+				if word = quoteWord(word); word != "" {
+					words = append(words, word)
+				}
+			}
+			term = strings.Join(words, " <-> ")
+			if len(words) > 1 {
+				term = "(" + term + ")"
+			}
+		} else {
+			end := strings.IndexFunc(q, unicode.IsSpace)
+			if end < 0 {
+				end = len(q)
+			}
+			word := q[:end]
+			q = q[end:]
+
+			if word == "OR" && !negate {
+				joinOr = len(terms) > 0
+				continue
+			}
+			prefix := strings.HasSuffix(word, "*")
+			term = quoteWord(strings.TrimRight(word, "*"))
+			if term != "" && prefix {
+				term += ":*"
+			}
+		}
+
+		if term != "" && negate {
+			term = "!" + term
+		}
+		addTerm(term)
+	}
+
+	return strings.Join(terms, " ")
+}
+
+// quoteWord quotes a word as a to_tsquery operand, which can never break the query.
+// Words without any letters or digits have nothing to search for and come back empty.
+func quoteWord(word string) string {
+	if !strings.ContainsFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+		return ""
+	}
+	word = strings.ReplaceAll(word, `\`, `\\`)
+	word = strings.ReplaceAll(word, "'", "''")
+	return "'" + strings.ToLower(word) + "'"
+}
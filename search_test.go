@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestBuildTSQuery(t *testing.T) {
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{"postgres", "'postgres'"},
+		{"  Go   generics ", "'go' & 'generics'"},
+		{`"full text" search`, "('full' <-> 'text') & 'search'"},
+		{"index*", "'index':*"},
+		{"rust -crypto", "'rust' & !'crypto'"},
+		{`-"breaking news"`, "!('breaking' <-> 'news')"},
+		{"go OR rust", "'go' | 'rust'"},
+		{"OR go", "'go'"},
+		{"c++ & it's", "'c++' & 'it''s'"},
+		{"e-mail node.js", "'e-mail' & 'node.js'"},
+		{`"don't panic" re-run*`, "('don''t' <-> 'panic') & 're-run':*"},
+		{`back\slash 'quoted'`, `'back\\slash' & '''quoted'''`},
+		{`"unterminated phrase`, "('unterminated' <-> 'phrase')"},
+		{"!!! *", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := buildTSQuery(tt.q); got != tt.want {
+			t.Errorf("buildTSQuery(%q) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
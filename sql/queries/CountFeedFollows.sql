@@ -1,4 +0,0 @@
--- name: CountFeedFollows :one
-SELECT COUNT(*)
-FROM feed_follows
-WHERE feed_id = $1;
\ No newline at end of file
@@ -0,0 +1,96 @@
+-- name: UpsertDigestSettings :one
+INSERT INTO digest_settings (
+        user_id,
+        created_at,
+        updated_at,
+        email,
+        frequency,
+        minute_of_day,
+        weekday,
+        timezone,
+        folder_ids,
+        next_send_at,
+        confirm_token
+    )
+VALUES (
+        $1,
+        $2,
+        $3,
+        $4,
+        $5,
+        $6,
+        $7,
+        $8,
+        $9,
+        $10,
+        encode(gen_random_bytes(32), 'hex')
+    ) ON CONFLICT (user_id) DO
+UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    email_confirmed_at = CASE
+        WHEN digest_settings.email = EXCLUDED.email THEN digest_settings.email_confirmed_at
+    END,
+    confirm_token = CASE
+        WHEN digest_settings.email = EXCLUDED.email THEN digest_settings.confirm_token
+        ELSE EXCLUDED.confirm_token
+    END,
+    email = EXCLUDED.email,
+    frequency = EXCLUDED.frequency,
+    minute_of_day = EXCLUDED.minute_of_day,
+    weekday = EXCLUDED.weekday,
+    timezone = EXCLUDED.timezone,
+    folder_ids = EXCLUDED.folder_ids,
+    next_send_at = EXCLUDED.next_send_at
+RETURNING *;
+-- name: GetDigestSettings :one
+SELECT *
+FROM digest_settings
+WHERE user_id = $1;
+-- name: ConfirmDigestEmail :one
+UPDATE digest_settings
+SET email_confirmed_at = $2,
+    confirm_token = NULL
+WHERE confirm_token = $1
+RETURNING *;
+-- name: DeleteDigestSettings :execrows
+DELETE FROM digest_settings
+WHERE user_id = $1;
+-- name: ClaimDueDigests :many
+UPDATE digest_settings
+SET next_send_at = sqlc.arg(lease_until)::timestamp
+WHERE user_id IN (
+        SELECT user_id
+        FROM digest_settings
+        WHERE next_send_at <= sqlc.arg(now)::timestamp
+            AND email_confirmed_at IS NOT NULL
+        ORDER BY next_send_at ASC
+        LIMIT sqlc.arg(batch_size) FOR
+        UPDATE SKIP LOCKED
+    )
+RETURNING *;
+-- name: MarkDigestSent :exec
+UPDATE digest_settings
+SET last_sent_at = $2,
+    next_send_at = $3
+WHERE user_id = $1;
+-- name: GetDigestPosts :many
+SELECT posts.*,
+    feeds.name AS feed_name
+FROM posts
+    JOIN feeds ON feeds.id = posts.feed_id
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = @user_id
+    AND posts.created_at > @since::timestamp
+    AND (
+        COALESCE(cardinality(@folder_ids::uuid []), 0) = 0
+        OR feed_follows.folder_id = ANY(@folder_ids::uuid [])
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.user_id = @user_id
+            AND post_reads.post_id = posts.id
+    )
+ORDER BY feeds.name ASC,
+    posts.published_at DESC
+LIMIT @page_size;
@@ -1,12 +1,66 @@
 -- name: CreateFeedFollow :one
-INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO feed_follows (
+        id,
+        created_at,
+        updated_at,
+        user_id,
+        feed_id,
+        folder_id
+    )
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 -- name: GetFeedFollows :many
 SELECT *
 FROM feed_follows
 WHERE user_id = $1;
--- name: DeleteFeedFollow :exec 
+-- name: DeleteFeedFollow :execrows
 DELETE FROM feed_follows
 WHERE user_id = $1
-    AND feed_id = $2;
\ No newline at end of file
+    AND feed_id = $2;
+-- name: DeleteFeedFollowByID :execrows
+DELETE FROM feed_follows
+WHERE id = $1
+    AND user_id = $2;
+-- name: GetFeedFollowByID :one
+SELECT *
+FROM feed_follows
+WHERE id = $1
+    AND user_id = $2;
+-- name: SetFeedFollowFolder :one
+UPDATE feed_follows
+SET folder_id = $3,
+    updated_at = $4
+WHERE id = $1
+    AND user_id = $2
+RETURNING *;
+-- name: GetFeedFollowsForExport :many
+SELECT feeds.name,
+    feeds.url,
+    feeds.site_url,
+    folders.name AS folder_name
+FROM feed_follows
+    JOIN feeds ON feeds.id = feed_follows.feed_id
+    LEFT JOIN folders ON folders.id = feed_follows.folder_id
+WHERE feed_follows.user_id = $1
+ORDER BY folders.name ASC NULLS FIRST,
+    feeds.name ASC;
+-- name: CreateFeedFollowIfMissing :one
+INSERT INTO feed_follows (
+        id,
+        created_at,
+        updated_at,
+        user_id,
+        feed_id,
+        folder_id
+    )
+VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (user_id, feed_id) DO NOTHING
+RETURNING *;
+-- name: GetFeedFollowForFeed :one
+SELECT *
+FROM feed_follows
+WHERE user_id = $1
+    AND feed_id = $2;
+-- name: GetFeedFollowerIDs :many
+SELECT user_id
+FROM feed_follows
+WHERE feed_id = $1;
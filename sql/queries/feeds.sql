@@ -1,21 +1,29 @@
 -- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO feeds (
+        id,
+        created_at,
+        updated_at,
+        name,
+        url,
+        user_id,
+        site_url
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (url) DO NOTHING
 RETURNING *;
 -- name: GetFeeds :many 
 SELECT *
 FROM feeds;
--- name: GetNextFeedsToFetch :many 
-SELECT *
-FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT $1;
 -- name: MarkFeedAsFetched :one
 UPDATE feeds
 SET last_fetched_at = Now(),
     updated_at = Now()
 WHERE id = $1
 RETURNING *;
+-- name: SetFeedNextFetchAt :exec
+UPDATE feeds
+SET next_fetch_at = $2,
+    updated_at = Now()
+WHERE id = $1;
 -- name: DeleteFeed :exec
 DELETE FROM feeds
 WHERE id = $1;
@@ -23,4 +31,58 @@ WHERE id = $1;
 SELECT *
 FROM feeds
 WHERE id = $1
-LIMIT 1;
\ No newline at end of file
+LIMIT 1;
+-- name: GetFeedByURL :one
+SELECT *
+FROM feeds
+WHERE url = $1
+LIMIT 1;
+-- name: SetFeedSiteURL :exec
+UPDATE feeds
+SET site_url = $2,
+    updated_at = Now()
+WHERE id = $1
+    AND site_url IS DISTINCT FROM $2;
+-- name: UpdateFeed :one
+UPDATE feeds
+SET name = @name,
+    next_fetch_at = CASE
+        WHEN url = @url THEN next_fetch_at
+        ELSE NULL
+    END,
+    url = @url,
+    updated_at = @updated_at
+WHERE id = @id
+RETURNING *;
+-- name: MarkOrphanedFeeds :execrows
+UPDATE feeds
+SET orphaned_since = sqlc.arg(now)::timestamp
+WHERE orphaned_since IS NULL
+    AND NOT EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE feed_follows.feed_id = feeds.id
+    );
+-- name: UnmarkFollowedFeeds :execrows
+UPDATE feeds
+SET orphaned_since = NULL
+WHERE orphaned_since IS NOT NULL
+    AND EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE feed_follows.feed_id = feeds.id
+    );
+-- name: DeleteOrphanedFeeds :execrows
+DELETE FROM feeds
+WHERE orphaned_since < sqlc.arg(orphaned_before)::timestamp
+    AND NOT EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE feed_follows.feed_id = feeds.id
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts
+            JOIN post_stars ON post_stars.post_id = posts.id
+        WHERE posts.feed_id = feeds.id
+    );
\ No newline at end of file
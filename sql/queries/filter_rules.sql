@@ -0,0 +1,47 @@
+-- name: CreateFilterRule :one
+INSERT INTO filter_rules (
+        id,
+        created_at,
+        updated_at,
+        user_id,
+        feed_id,
+        field,
+        match_type,
+        pattern
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+-- name: GetFilterRulesWithCounts :many
+SELECT filter_rules.*,
+    (
+        SELECT count(*)
+        FROM posts
+            JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+        WHERE feed_follows.user_id = filter_rules.user_id
+            AND (
+                filter_rules.feed_id IS NULL
+                OR posts.feed_id = filter_rules.feed_id
+            )
+            AND filter_rule_matches(
+                filter_rules.field,
+                filter_rules.match_type,
+                filter_rules.pattern,
+                posts.title,
+                posts.description,
+                posts.author,
+                posts.categories
+            )
+    )::bigint AS suppressed_count
+FROM filter_rules
+WHERE filter_rules.user_id = $1
+ORDER BY filter_rules.created_at ASC;
+-- name: DeleteFilterRule :execrows
+DELETE FROM filter_rules
+WHERE id = $1
+    AND user_id = $2;
+-- name: CountFilterRulesForUser :one
+SELECT count(*)
+FROM filter_rules
+WHERE user_id = $1;
+-- name: CheckFilterRegex :exec
+SELECT ''::text ~* sqlc.arg(pattern)::text;
@@ -0,0 +1,31 @@
+-- name: CreateFolder :one
+INSERT INTO folders (id, created_at, updated_at, user_id, name)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+-- name: GetFolders :many
+SELECT *
+FROM folders
+WHERE user_id = $1
+ORDER BY name;
+-- name: GetFolderForUser :one
+SELECT *
+FROM folders
+WHERE id = $1
+    AND user_id = $2;
+-- name: RenameFolder :one
+UPDATE folders
+SET name = $3,
+    updated_at = $4
+WHERE id = $1
+    AND user_id = $2
+RETURNING *;
+-- name: DeleteFolder :execrows
+DELETE FROM folders
+WHERE id = $1
+    AND user_id = $2;
+-- name: EnsureFolder :one
+INSERT INTO folders (id, created_at, updated_at, user_id, name)
+VALUES ($1, $2, $3, $4, $5) ON CONFLICT (user_id, name) DO
+UPDATE
+SET name = EXCLUDED.name
+RETURNING *;
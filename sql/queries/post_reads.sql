@@ -0,0 +1,50 @@
+-- name: MarkPostsRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id,
+    posts.id,
+    @now::timestamp
+FROM posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = @user_id
+    AND posts.id = ANY(@post_ids::uuid []) ON CONFLICT (user_id, post_id) DO NOTHING;
+-- name: MarkPostsReadUpTo :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id,
+    posts.id,
+    @now::timestamp
+FROM posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = @user_id
+    AND posts.published_at <= @up_to::timestamp
+    AND (
+        sqlc.narg(feed_id)::uuid IS NULL
+        OR posts.feed_id = sqlc.narg(feed_id)::uuid
+    ) ON CONFLICT (user_id, post_id) DO NOTHING;
+-- name: MarkPostsUnread :execrows
+DELETE FROM post_reads
+WHERE user_id = @user_id
+    AND post_id = ANY(@post_ids::uuid []);
+-- name: GetUnreadCounts :many
+SELECT feed_follows.feed_id,
+    COUNT(posts.id) AS unread
+FROM feed_follows
+    LEFT JOIN posts ON posts.feed_id = feed_follows.feed_id
+    AND NOT EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id
+            AND post_reads.post_id = posts.id
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts AS earlier
+            JOIN feed_follows AS earlier_follows ON earlier_follows.feed_id = earlier.feed_id
+        WHERE earlier_follows.user_id = feed_follows.user_id
+            AND earlier.canonical_url = posts.canonical_url
+            AND (earlier.created_at, earlier.id) < (posts.created_at, posts.id)
+            AND NOT post_hidden_for_user(earlier_follows.user_id, earlier)
+    )
+    AND NOT post_hidden_for_user(feed_follows.user_id, posts)
+WHERE feed_follows.user_id = $1
+GROUP BY feed_follows.feed_id
+ORDER BY feed_follows.feed_id;
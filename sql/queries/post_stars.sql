@@ -0,0 +1,39 @@
+-- name: StarPost :exec
+INSERT INTO post_stars (user_id, post_id, created_at)
+VALUES ($1, $2, $3) ON CONFLICT (user_id, post_id) DO NOTHING;
+-- name: UnstarPost :exec
+DELETE FROM post_stars
+WHERE user_id = $1
+    AND post_id = $2;
+-- name: GetStarredPosts :many
+SELECT sqlc.embed(posts),
+    post_stars.created_at AS starred_at,
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.user_id = post_stars.user_id
+            AND post_reads.post_id = posts.id
+    ) AS read
+FROM post_stars
+    JOIN posts ON posts.id = post_stars.post_id
+WHERE post_stars.user_id = @user_id
+    AND (
+        sqlc.narg(cursor_at)::timestamp IS NULL
+        OR (
+            @before::bool
+            AND (post_stars.created_at, posts.id) > (sqlc.narg(cursor_at)::timestamp, @cursor_id::uuid)
+        )
+        OR (
+            NOT @before::bool
+            AND (post_stars.created_at, posts.id) < (sqlc.narg(cursor_at)::timestamp, @cursor_id::uuid)
+        )
+    )
+ORDER BY CASE
+        WHEN @before::bool THEN post_stars.created_at
+    END ASC,
+    CASE
+        WHEN @before::bool THEN posts.id
+    END ASC,
+    post_stars.created_at DESC,
+    posts.id DESC
+LIMIT @page_size;
@@ -1,30 +1,543 @@
--- name: CreatePost :one
-INSERT INTO posts (
-        id,
-        created_at,
-        updated_at,
-        name,
-        -- ✅ Ensure "name" is included here
-        title,
-        description,
-        published_at,
-        url,
-        feed_id
-    )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-RETURNING id,
-    created_at,
-    updated_at,
-    name,
-    title,
-    description,
-    published_at,
-    url,
-    feed_id;
+-- name: CreatePosts :many
+WITH inserted AS (
+    INSERT INTO posts (
+            id,
+            created_at,
+            updated_at,
+            name,
+            title,
+            description,
+            published_at,
+            url,
+            feed_id,
+            canonical_url,
+            content,
+            author,
+            categories
+        )
+    SELECT item.id,
+        @now::timestamp,
+        @now::timestamp,
+        @name::text,
+        item.title,
+        NULLIF(item.description, ''),
+        item.published_at,
+        item.url,
+        @feed_id::uuid,
+        item.canonical_url,
+        NULLIF(item.content, ''),
+        NULLIF(item.author, ''),
+        string_to_array(item.categories, chr(31))
+    FROM unnest(
+            @ids::uuid [],
+            @titles::text [],
+            @descriptions::text [],
+            @published_ats::timestamp [],
+            @urls::text [],
+            @canonical_urls::text [],
+            @contents::text [],
+            @authors::text [],
+            @categories::text []
+        ) AS item(
+            id,
+            title,
+            description,
+            published_at,
+            url,
+            canonical_url,
+            content,
+            author,
+            categories
+        ) ON CONFLICT (feed_id, canonical_url) DO NOTHING
+    RETURNING *
+),
+queued AS (
+    INSERT INTO webhook_deliveries (
+            id,
+            created_at,
+            updated_at,
+            webhook_id,
+            post_id,
+            status,
+            attempts,
+            run_at
+        )
+    SELECT gen_random_uuid(),
+        @now::timestamp,
+        @now::timestamp,
+        webhooks.id,
+        inserted.id,
+        'pending',
+        0,
+        @now::timestamp
+    FROM inserted
+        JOIN feed_follows ON feed_follows.feed_id = inserted.feed_id
+        JOIN webhooks ON webhooks.user_id = feed_follows.user_id
+    WHERE (
+            cardinality(webhooks.feed_ids) = 0
+            OR inserted.feed_id = ANY(webhooks.feed_ids)
+        )
+        AND (
+            cardinality(webhooks.keywords) = 0
+            OR EXISTS (
+                SELECT 1
+                FROM unnest(webhooks.keywords) AS keyword
+                WHERE strpos(
+                        lower(concat_ws(' ', inserted.title, inserted.description)),
+                        lower(keyword)
+                    ) > 0
+            )
+        ) ON CONFLICT (webhook_id, post_id) DO NOTHING
+)
+SELECT *
+FROM inserted;
 -- name: GetPostsForUser :many
+SELECT sqlc.embed(posts),
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id
+            AND post_reads.post_id = posts.id
+    ) AS read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.user_id = feed_follows.user_id
+            AND post_stars.post_id = posts.id
+    ) AS starred,
+    post_hidden_for_user(feed_follows.user_id, posts) AS hidden
+FROM posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = @user_id
+    AND (
+        COALESCE(cardinality(@feed_ids::uuid []), 0) = 0
+        OR posts.feed_id = ANY(@feed_ids::uuid [])
+    )
+    AND (
+        sqlc.narg(folder_id)::uuid IS NULL
+        OR feed_follows.folder_id = sqlc.narg(folder_id)::uuid
+    )
+    AND (
+        sqlc.narg(search_query)::text IS NULL
+        OR posts.search @@ to_tsquery('english', sqlc.narg(search_query)::text)
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts AS earlier
+            JOIN feed_follows AS earlier_follows ON earlier_follows.feed_id = earlier.feed_id
+        WHERE earlier_follows.user_id = @user_id
+            AND earlier.canonical_url = posts.canonical_url
+            AND (earlier.created_at, earlier.id) < (posts.created_at, posts.id)
+            AND (
+                COALESCE(cardinality(@feed_ids::uuid []), 0) = 0
+                OR earlier.feed_id = ANY(@feed_ids::uuid [])
+            )
+            AND (
+                sqlc.narg(folder_id)::uuid IS NULL
+                OR earlier_follows.folder_id = sqlc.narg(folder_id)::uuid
+            )
+            AND (
+                sqlc.narg(search_query)::text IS NULL
+                OR earlier.search @@ to_tsquery('english', sqlc.narg(search_query)::text)
+            )
+            AND (
+                @show_hidden::bool
+                OR NOT post_hidden_for_user(earlier_follows.user_id, earlier)
+            )
+            AND (
+                sqlc.narg(since)::timestamp IS NULL
+                OR earlier.published_at >= sqlc.narg(since)::timestamp
+            )
+            AND (
+                sqlc.narg(until)::timestamp IS NULL
+                OR earlier.published_at < sqlc.narg(until)::timestamp
+            )
+    )
+    AND (
+        NOT @unread_only::bool
+        OR NOT EXISTS (
+            SELECT 1
+            FROM post_reads
+            WHERE post_reads.user_id = feed_follows.user_id
+                AND post_reads.post_id = posts.id
+        )
+    )
+    AND (
+        @show_hidden::bool
+        OR NOT post_hidden_for_user(feed_follows.user_id, posts)
+    )
+    AND (
+        sqlc.narg(since)::timestamp IS NULL
+        OR posts.published_at >= sqlc.narg(since)::timestamp
+    )
+    AND (
+        sqlc.narg(until)::timestamp IS NULL
+        OR posts.published_at < sqlc.narg(until)::timestamp
+    )
+    AND (
+        sqlc.narg(cursor_at)::timestamp IS NULL
+        OR (posts.published_at, posts.id) < (sqlc.narg(cursor_at)::timestamp, @cursor_id::uuid)
+    )
+ORDER BY posts.published_at DESC,
+    posts.id DESC
+LIMIT @page_size;
+-- name: GetPostsForUserOldestFirst :many
+SELECT sqlc.embed(posts),
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id
+            AND post_reads.post_id = posts.id
+    ) AS read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.user_id = feed_follows.user_id
+            AND post_stars.post_id = posts.id
+    ) AS starred,
+    post_hidden_for_user(feed_follows.user_id, posts) AS hidden
+FROM posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = @user_id
+    AND (
+        COALESCE(cardinality(@feed_ids::uuid []), 0) = 0
+        OR posts.feed_id = ANY(@feed_ids::uuid [])
+    )
+    AND (
+        sqlc.narg(folder_id)::uuid IS NULL
+        OR feed_follows.folder_id = sqlc.narg(folder_id)::uuid
+    )
+    AND (
+        sqlc.narg(search_query)::text IS NULL
+        OR posts.search @@ to_tsquery('english', sqlc.narg(search_query)::text)
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts AS earlier
+            JOIN feed_follows AS earlier_follows ON earlier_follows.feed_id = earlier.feed_id
+        WHERE earlier_follows.user_id = @user_id
+            AND earlier.canonical_url = posts.canonical_url
+            AND (earlier.created_at, earlier.id) < (posts.created_at, posts.id)
+            AND (
+                COALESCE(cardinality(@feed_ids::uuid []), 0) = 0
+                OR earlier.feed_id = ANY(@feed_ids::uuid [])
+            )
+            AND (
+                sqlc.narg(folder_id)::uuid IS NULL
+                OR earlier_follows.folder_id = sqlc.narg(folder_id)::uuid
+            )
+            AND (
+                sqlc.narg(search_query)::text IS NULL
+                OR earlier.search @@ to_tsquery('english', sqlc.narg(search_query)::text)
+            )
+            AND (
+                @show_hidden::bool
+                OR NOT post_hidden_for_user(earlier_follows.user_id, earlier)
+            )
+            AND (
+                sqlc.narg(since)::timestamp IS NULL
+                OR earlier.published_at >= sqlc.narg(since)::timestamp
+            )
+            AND (
+                sqlc.narg(until)::timestamp IS NULL
+                OR earlier.published_at < sqlc.narg(until)::timestamp
+            )
+    )
+    AND (
+        NOT @unread_only::bool
+        OR NOT EXISTS (
+            SELECT 1
+            FROM post_reads
+            WHERE post_reads.user_id = feed_follows.user_id
+                AND post_reads.post_id = posts.id
+        )
+    )
+    AND (
+        @show_hidden::bool
+        OR NOT post_hidden_for_user(feed_follows.user_id, posts)
+    )
+    AND (
+        sqlc.narg(since)::timestamp IS NULL
+        OR posts.published_at >= sqlc.narg(since)::timestamp
+    )
+    AND (
+        sqlc.narg(until)::timestamp IS NULL
+        OR posts.published_at < sqlc.narg(until)::timestamp
+    )
+    AND (
+        sqlc.narg(cursor_at)::timestamp IS NULL
+        OR (posts.published_at, posts.id) > (sqlc.narg(cursor_at)::timestamp, @cursor_id::uuid)
+    )
+ORDER BY posts.published_at ASC,
+    posts.id ASC
+LIMIT @page_size;
+-- name: GetPostsForUserByIngested :many
+SELECT sqlc.embed(posts),
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id
+            AND post_reads.post_id = posts.id
+    ) AS read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.user_id = feed_follows.user_id
+            AND post_stars.post_id = posts.id
+    ) AS starred,
+    post_hidden_for_user(feed_follows.user_id, posts) AS hidden
+FROM posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = @user_id
+    AND (
+        COALESCE(cardinality(@feed_ids::uuid []), 0) = 0
+        OR posts.feed_id = ANY(@feed_ids::uuid [])
+    )
+    AND (
+        sqlc.narg(folder_id)::uuid IS NULL
+        OR feed_follows.folder_id = sqlc.narg(folder_id)::uuid
+    )
+    AND (
+        sqlc.narg(search_query)::text IS NULL
+        OR posts.search @@ to_tsquery('english', sqlc.narg(search_query)::text)
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts AS earlier
+            JOIN feed_follows AS earlier_follows ON earlier_follows.feed_id = earlier.feed_id
+        WHERE earlier_follows.user_id = @user_id
+            AND earlier.canonical_url = posts.canonical_url
+            AND (earlier.created_at, earlier.id) < (posts.created_at, posts.id)
+            AND (
+                COALESCE(cardinality(@feed_ids::uuid []), 0) = 0
+                OR earlier.feed_id = ANY(@feed_ids::uuid [])
+            )
+            AND (
+                sqlc.narg(folder_id)::uuid IS NULL
+                OR earlier_follows.folder_id = sqlc.narg(folder_id)::uuid
+            )
+            AND (
+                sqlc.narg(search_query)::text IS NULL
+                OR earlier.search @@ to_tsquery('english', sqlc.narg(search_query)::text)
+            )
+            AND (
+                @show_hidden::bool
+                OR NOT post_hidden_for_user(earlier_follows.user_id, earlier)
+            )
+            AND (
+                sqlc.narg(since)::timestamp IS NULL
+                OR earlier.created_at >= sqlc.narg(since)::timestamp
+            )
+            AND (
+                sqlc.narg(until)::timestamp IS NULL
+                OR earlier.created_at < sqlc.narg(until)::timestamp
+            )
+    )
+    AND (
+        NOT @unread_only::bool
+        OR NOT EXISTS (
+            SELECT 1
+            FROM post_reads
+            WHERE post_reads.user_id = feed_follows.user_id
+                AND post_reads.post_id = posts.id
+        )
+    )
+    AND (
+        @show_hidden::bool
+        OR NOT post_hidden_for_user(feed_follows.user_id, posts)
+    )
+    AND (
+        sqlc.narg(since)::timestamp IS NULL
+        OR posts.created_at >= sqlc.narg(since)::timestamp
+    )
+    AND (
+        sqlc.narg(until)::timestamp IS NULL
+        OR posts.created_at < sqlc.narg(until)::timestamp
+    )
+    AND (
+        sqlc.narg(cursor_at)::timestamp IS NULL
+        OR (posts.created_at, posts.id) < (sqlc.narg(cursor_at)::timestamp, @cursor_id::uuid)
+    )
+ORDER BY posts.created_at DESC,
+    posts.id DESC
+LIMIT @page_size;
+-- name: GetPostsForUserByIngestedOldestFirst :many
+SELECT sqlc.embed(posts),
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id
+            AND post_reads.post_id = posts.id
+    ) AS read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.user_id = feed_follows.user_id
+            AND post_stars.post_id = posts.id
+    ) AS starred,
+    post_hidden_for_user(feed_follows.user_id, posts) AS hidden
+FROM posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = @user_id
+    AND (
+        COALESCE(cardinality(@feed_ids::uuid []), 0) = 0
+        OR posts.feed_id = ANY(@feed_ids::uuid [])
+    )
+    AND (
+        sqlc.narg(folder_id)::uuid IS NULL
+        OR feed_follows.folder_id = sqlc.narg(folder_id)::uuid
+    )
+    AND (
+        sqlc.narg(search_query)::text IS NULL
+        OR posts.search @@ to_tsquery('english', sqlc.narg(search_query)::text)
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts AS earlier
+            JOIN feed_follows AS earlier_follows ON earlier_follows.feed_id = earlier.feed_id
+        WHERE earlier_follows.user_id = @user_id
+            AND earlier.canonical_url = posts.canonical_url
+            AND (earlier.created_at, earlier.id) < (posts.created_at, posts.id)
+            AND (
+                COALESCE(cardinality(@feed_ids::uuid []), 0) = 0
+                OR earlier.feed_id = ANY(@feed_ids::uuid [])
+            )
+            AND (
+                sqlc.narg(folder_id)::uuid IS NULL
+                OR earlier_follows.folder_id = sqlc.narg(folder_id)::uuid
+            )
+            AND (
+                sqlc.narg(search_query)::text IS NULL
+                OR earlier.search @@ to_tsquery('english', sqlc.narg(search_query)::text)
+            )
+            AND (
+                @show_hidden::bool
+                OR NOT post_hidden_for_user(earlier_follows.user_id, earlier)
+            )
+            AND (
+                sqlc.narg(since)::timestamp IS NULL
+                OR earlier.created_at >= sqlc.narg(since)::timestamp
+            )
+            AND (
+                sqlc.narg(until)::timestamp IS NULL
+                OR earlier.created_at < sqlc.narg(until)::timestamp
+            )
+    )
+    AND (
+        NOT @unread_only::bool
+        OR NOT EXISTS (
+            SELECT 1
+            FROM post_reads
+            WHERE post_reads.user_id = feed_follows.user_id
+                AND post_reads.post_id = posts.id
+        )
+    )
+    AND (
+        @show_hidden::bool
+        OR NOT post_hidden_for_user(feed_follows.user_id, posts)
+    )
+    AND (
+        sqlc.narg(since)::timestamp IS NULL
+        OR posts.created_at >= sqlc.narg(since)::timestamp
+    )
+    AND (
+        sqlc.narg(until)::timestamp IS NULL
+        OR posts.created_at < sqlc.narg(until)::timestamp
+    )
+    AND (
+        sqlc.narg(cursor_at)::timestamp IS NULL
+        OR (posts.created_at, posts.id) > (sqlc.narg(cursor_at)::timestamp, @cursor_id::uuid)
+    )
+ORDER BY posts.created_at ASC,
+    posts.id ASC
+LIMIT @page_size;
+-- name: GetPostByID :one
+SELECT *
+FROM posts
+WHERE id = $1;
+-- name: GetPostDuplicates :many
+SELECT sqlc.embed(duplicates),
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.user_id = @user_id
+            AND post_reads.post_id = duplicates.id
+    ) AS read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.user_id = @user_id
+            AND post_stars.post_id = duplicates.id
+    ) AS starred
+FROM posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+    JOIN posts AS duplicates ON duplicates.canonical_url = posts.canonical_url
+WHERE posts.id = @id
+    AND feed_follows.user_id = @user_id
+ORDER BY duplicates.created_at ASC,
+    duplicates.id ASC;
+-- name: SearchPostsForUser :many
+SELECT sqlc.embed(posts),
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id
+            AND post_reads.post_id = posts.id
+    ) AS read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.user_id = feed_follows.user_id
+            AND post_stars.post_id = posts.id
+    ) AS starred,
+    ts_rank_cd(posts.search, query)::real AS rank,
+    ts_headline(
+        'english',
+        regexp_replace(
+            concat_ws(' ', posts.title, posts.description, posts.content),
+            '<[^>]*>',
+            ' ',
+            'g'
+        ),
+        query,
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10'
+    )::text AS snippet
+FROM posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+    CROSS JOIN to_tsquery('english', @query::text) AS query
+WHERE feed_follows.user_id = @user_id
+    AND posts.search @@ query
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts AS earlier
+            JOIN feed_follows AS earlier_follows ON earlier_follows.feed_id = earlier.feed_id
+        WHERE earlier_follows.user_id = @user_id
+            AND earlier.canonical_url = posts.canonical_url
+            AND (earlier.created_at, earlier.id) < (posts.created_at, posts.id)
+            AND earlier.search @@ query
+    )
+ORDER BY rank DESC,
+    posts.published_at DESC,
+    posts.id DESC
+LIMIT @page_size OFFSET @page_offset;
+-- name: GetPostsForUserAfterSeq :many
 SELECT posts.*
 FROM posts
     JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
 WHERE feed_follows.user_id = $1
-ORDER BY posts.created_at DESC
-LIMIT $2;
\ No newline at end of file
+    AND posts.seq > $2
+ORDER BY posts.seq ASC
+LIMIT $3;
+-- name: GetPostsForFeedInSeqRange :many
+SELECT *
+FROM posts
+WHERE feed_id = @feed_id
+    AND seq BETWEEN sqlc.arg(min_seq) AND sqlc.arg(max_seq)
+ORDER BY seq ASC;
+-- name: LockPostSeq :exec
+SELECT pg_advisory_xact_lock('posts'::regclass::oid::bigint);
+-- name: GetMaxPostSeq :one
+SELECT COALESCE(MAX(seq), 0)::bigint AS max_seq
+FROM posts;
+-- name: NotifyNewPosts :exec
+SELECT pg_notify('new_posts', sqlc.arg(payload)::text);
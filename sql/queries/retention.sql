@@ -0,0 +1,125 @@
+-- name: UpsertFeedRetention :one
+INSERT INTO feed_retention (
+        feed_id,
+        created_at,
+        updated_at,
+        max_age_days,
+        max_posts
+    )
+VALUES ($1, $2, $3, $4, $5) ON CONFLICT (feed_id) DO
+UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    max_age_days = EXCLUDED.max_age_days,
+    max_posts = EXCLUDED.max_posts
+RETURNING *;
+-- name: GetFeedRetention :one
+SELECT *
+FROM feed_retention
+WHERE feed_id = $1;
+-- name: DeleteFeedRetention :exec
+DELETE FROM feed_retention
+WHERE feed_id = $1;
+-- name: GetPrunablePostCounts :many
+WITH policy AS (
+    SELECT feeds.id AS feed_id,
+        COALESCE(
+            feed_retention.max_age_days,
+            @default_max_age_days::int
+        ) AS max_age_days,
+        COALESCE(
+            feed_retention.max_posts,
+            @default_max_posts::int
+        ) AS max_posts
+    FROM feeds
+        LEFT JOIN feed_retention ON feed_retention.feed_id = feeds.id
+),
+ranked AS (
+    SELECT posts.id,
+        posts.feed_id,
+        posts.created_at,
+        ROW_NUMBER() OVER (
+            PARTITION BY posts.feed_id
+            ORDER BY posts.created_at DESC,
+                posts.id DESC
+        ) AS position
+    FROM posts
+),
+expired AS (
+    SELECT ranked.id,
+        ranked.feed_id
+    FROM ranked
+        JOIN policy ON policy.feed_id = ranked.feed_id
+    WHERE (
+            (
+                policy.max_age_days > 0
+                AND ranked.created_at < @now::timestamp - make_interval(days => policy.max_age_days)
+            )
+            OR (
+                policy.max_posts > 0
+                AND ranked.position > policy.max_posts
+            )
+        )
+        AND NOT EXISTS (
+            SELECT 1
+            FROM post_stars
+            WHERE post_stars.post_id = ranked.id
+        )
+)
+SELECT feeds.id AS feed_id,
+    feeds.name,
+    feeds.url,
+    COUNT(*) AS posts
+FROM expired
+    JOIN feeds ON feeds.id = expired.feed_id
+GROUP BY feeds.id
+ORDER BY posts DESC;
+-- name: PrunePosts :execrows
+WITH policy AS (
+    SELECT feeds.id AS feed_id,
+        COALESCE(
+            feed_retention.max_age_days,
+            @default_max_age_days::int
+        ) AS max_age_days,
+        COALESCE(
+            feed_retention.max_posts,
+            @default_max_posts::int
+        ) AS max_posts
+    FROM feeds
+        LEFT JOIN feed_retention ON feed_retention.feed_id = feeds.id
+),
+ranked AS (
+    SELECT posts.id,
+        posts.feed_id,
+        posts.created_at,
+        ROW_NUMBER() OVER (
+            PARTITION BY posts.feed_id
+            ORDER BY posts.created_at DESC,
+                posts.id DESC
+        ) AS position
+    FROM posts
+),
+expired AS (
+    SELECT ranked.id
+    FROM ranked
+        JOIN policy ON policy.feed_id = ranked.feed_id
+    WHERE (
+            (
+                policy.max_age_days > 0
+                AND ranked.created_at < @now::timestamp - make_interval(days => policy.max_age_days)
+            )
+            OR (
+                policy.max_posts > 0
+                AND ranked.position > policy.max_posts
+            )
+        )
+        AND NOT EXISTS (
+            SELECT 1
+            FROM post_stars
+            WHERE post_stars.post_id = ranked.id
+        )
+)
+DELETE FROM posts
+WHERE id IN (
+        SELECT id
+        FROM expired
+    );
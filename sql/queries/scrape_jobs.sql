@@ -0,0 +1,118 @@
+-- name: EnqueueDueFeeds :execrows
+INSERT INTO scrape_jobs (
+        id,
+        created_at,
+        updated_at,
+        feed_id,
+        status,
+        attempts,
+        run_at
+    )
+SELECT gen_random_uuid(),
+    sqlc.arg(now)::timestamp,
+    sqlc.arg(now)::timestamp,
+    feeds.id,
+    'pending',
+    0,
+    sqlc.arg(now)::timestamp
+FROM feeds
+WHERE (
+        feeds.next_fetch_at IS NULL
+        OR feeds.next_fetch_at <= sqlc.arg(now)::timestamp
+    )
+    AND EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE feed_follows.feed_id = feeds.id
+    ) ON CONFLICT (feed_id) DO NOTHING;
+-- name: ClaimScrapeJobs :many
+UPDATE scrape_jobs
+SET status = 'running',
+    attempts = attempts + 1,
+    lease_expires_at = sqlc.arg(lease_until)::timestamp,
+    updated_at = sqlc.arg(now)::timestamp
+WHERE id IN (
+        SELECT id
+        FROM scrape_jobs
+        WHERE (
+                status = 'pending'
+                AND run_at <= sqlc.arg(now)::timestamp
+            )
+            OR (
+                status = 'running'
+                AND lease_expires_at <= sqlc.arg(now)::timestamp
+            )
+        ORDER BY run_at ASC
+        LIMIT sqlc.arg(batch_size) FOR
+        UPDATE SKIP LOCKED
+    )
+RETURNING *;
+-- name: ClaimScrapeJobForFeed :one
+INSERT INTO scrape_jobs (
+        id,
+        created_at,
+        updated_at,
+        feed_id,
+        status,
+        attempts,
+        run_at,
+        lease_expires_at
+    )
+VALUES (
+        gen_random_uuid(),
+        sqlc.arg(now)::timestamp,
+        sqlc.arg(now)::timestamp,
+        sqlc.arg(feed_id)::uuid,
+        'running',
+        1,
+        sqlc.arg(now)::timestamp,
+        sqlc.arg(lease_until)::timestamp
+    ) ON CONFLICT (feed_id) DO
+UPDATE
+SET status = 'running',
+    attempts = scrape_jobs.attempts + 1,
+    lease_expires_at = EXCLUDED.lease_expires_at,
+    updated_at = EXCLUDED.updated_at
+WHERE scrape_jobs.status <> 'running'
+    OR scrape_jobs.lease_expires_at <= EXCLUDED.updated_at
+RETURNING *;
+-- name: CompleteScrapeJob :exec
+DELETE FROM scrape_jobs
+WHERE id = $1;
+-- name: RetryScrapeJobLater :exec
+UPDATE scrape_jobs
+SET status = 'pending',
+    run_at = $2,
+    lease_expires_at = NULL,
+    last_error = $3,
+    last_status_code = $4,
+    updated_at = NOW()
+WHERE id = $1;
+-- name: DeadLetterScrapeJob :exec
+UPDATE scrape_jobs
+SET status = 'dead',
+    lease_expires_at = NULL,
+    last_error = $2,
+    last_status_code = $3,
+    updated_at = NOW()
+WHERE id = $1;
+-- name: ListScrapeJobs :many
+SELECT *
+FROM scrape_jobs
+WHERE status = $1
+ORDER BY updated_at DESC
+LIMIT $2;
+-- name: RetryDeadScrapeJob :one
+UPDATE scrape_jobs
+SET status = 'pending',
+    attempts = 0,
+    run_at = NOW(),
+    lease_expires_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+    AND status = 'dead'
+RETURNING *;
+-- name: DeleteDeadScrapeJobForFeed :exec
+DELETE FROM scrape_jobs
+WHERE feed_id = $1
+    AND status = 'dead';
@@ -11,4 +11,19 @@ RETURNING *;
 -- name: GetUserByAPIKey :one
 SELECT *
 FROM users
-WHERE api_key = $1;
\ No newline at end of file
+WHERE api_key = $1;
+-- name: GetUserByID :one
+SELECT *
+FROM users
+WHERE id = $1;
+-- name: RotateUserFeedToken :one
+UPDATE users
+SET feed_token = encode(gen_random_bytes(32), 'hex'),
+    updated_at = $2
+WHERE id = $1
+RETURNING *;
+-- name: ClearUserFeedToken :exec
+UPDATE users
+SET feed_token = NULL,
+    updated_at = $2
+WHERE id = $1;
@@ -0,0 +1,99 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (
+        id,
+        created_at,
+        updated_at,
+        user_id,
+        url,
+        secret,
+        feed_ids,
+        keywords
+    )
+VALUES (
+        $1,
+        $2,
+        $3,
+        $4,
+        $5,
+        encode(gen_random_bytes(32), 'hex'),
+        $6,
+        $7
+    )
+RETURNING *;
+-- name: GetWebhooksForUser :many
+SELECT *
+FROM webhooks
+WHERE user_id = $1
+ORDER BY created_at ASC;
+-- name: GetWebhookByID :one
+SELECT *
+FROM webhooks
+WHERE id = $1;
+-- name: GetWebhookForUser :one
+SELECT *
+FROM webhooks
+WHERE id = $1
+    AND user_id = $2;
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE id = $1
+    AND user_id = $2;
+-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET status = 'running',
+    attempts = attempts + 1,
+    lease_expires_at = sqlc.arg(lease_until)::timestamp,
+    updated_at = sqlc.arg(now)::timestamp
+WHERE id IN (
+        SELECT id
+        FROM webhook_deliveries
+        WHERE (
+                status = 'pending'
+                AND run_at <= sqlc.arg(now)::timestamp
+            )
+            OR (
+                status = 'running'
+                AND lease_expires_at <= sqlc.arg(now)::timestamp
+            )
+        ORDER BY run_at ASC
+        LIMIT sqlc.arg(batch_size) FOR
+        UPDATE SKIP LOCKED
+    )
+RETURNING *;
+-- name: CompleteWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET status = 'succeeded',
+    lease_expires_at = NULL,
+    last_status_code = $2,
+    last_error = NULL,
+    delivered_at = $3,
+    updated_at = $3
+WHERE id = $1;
+-- name: RetryWebhookDeliveryLater :exec
+UPDATE webhook_deliveries
+SET status = 'pending',
+    run_at = $2,
+    lease_expires_at = NULL,
+    last_status_code = $3,
+    last_error = $4,
+    updated_at = NOW()
+WHERE id = $1;
+-- name: FailWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET status = 'failed',
+    lease_expires_at = NULL,
+    last_status_code = $2,
+    last_error = $3,
+    updated_at = NOW()
+WHERE id = $1;
+-- name: GetWebhookDeliveries :many
+SELECT *
+FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY created_at DESC,
+    id DESC
+LIMIT $2;
+-- name: DeleteFinishedWebhookDeliveriesBefore :execrows
+DELETE FROM webhook_deliveries
+WHERE status IN ('succeeded', 'failed')
+    AND updated_at < $1;
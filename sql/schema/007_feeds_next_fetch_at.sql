@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN next_fetch_at TIMESTAMP;
+CREATE INDEX feeds_next_fetch_at_idx ON feeds (next_fetch_at);
+-- +goose Down
+DROP INDEX feeds_next_fetch_at_idx;
+ALTER TABLE feeds DROP COLUMN next_fetch_at;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN lease_expires_at TIMESTAMP;
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN lease_expires_at;
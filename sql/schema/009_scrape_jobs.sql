@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE scrape_jobs (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    feed_id UUID NOT NULL UNIQUE REFERENCES feeds(id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'running', 'dead')),
+    attempts INT NOT NULL DEFAULT 0,
+    run_at TIMESTAMP NOT NULL,
+    lease_expires_at TIMESTAMP,
+    last_error TEXT,
+    last_status_code INT
+);
+CREATE INDEX scrape_jobs_status_run_at_idx ON scrape_jobs (status, run_at);
+-- Jobs carry their own lease now
+ALTER TABLE feeds DROP COLUMN lease_expires_at;
+-- +goose Down
+ALTER TABLE feeds
+ADD COLUMN lease_expires_at TIMESTAMP;
+DROP TABLE scrape_jobs;
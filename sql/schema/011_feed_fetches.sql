@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE feed_fetches (
+    id UUID PRIMARY KEY,
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    fetched_at TIMESTAMP NOT NULL,
+    status_code INT,
+    bytes BIGINT NOT NULL,
+    duration_ms INT NOT NULL,
+    items_seen INT NOT NULL,
+    items_inserted INT NOT NULL,
+    error TEXT
+);
+CREATE INDEX feed_fetches_feed_id_fetched_at_idx ON feed_fetches (feed_id, fetched_at DESC);
+CREATE INDEX feed_fetches_fetched_at_idx ON feed_fetches (fetched_at);
+-- +goose Down
+DROP TABLE feed_fetches;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Per-feed overrides of the global retention policy; NULL falls back to the default and 0 keeps everything
+CREATE TABLE feed_retention (
+    feed_id UUID PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    max_age_days INT CHECK (max_age_days >= 0),
+    max_posts INT CHECK (max_posts >= 0)
+);
+-- +goose Down
+DROP TABLE feed_retention;
@@ -0,0 +1,24 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN canonical_url TEXT;
+-- Existing posts only have their raw URL to go on
+UPDATE posts
+SET canonical_url = url;
+ALTER TABLE posts
+ALTER COLUMN canonical_url
+SET NOT NULL;
+-- The same article may now be stored once per feed that carries it
+ALTER TABLE posts DROP CONSTRAINT posts_url_key;
+-- Posts sharing a canonical URL form a duplicate group
+CREATE INDEX posts_canonical_url_idx ON posts (canonical_url);
+-- The same article can't appear twice in one feed under different tracking parameters
+CREATE UNIQUE INDEX posts_feed_id_canonical_url_idx ON posts (feed_id, canonical_url);
+-- +goose Down
+DROP INDEX posts_feed_id_canonical_url_idx;
+DROP INDEX posts_canonical_url_idx;
+DELETE FROM posts a USING posts b
+WHERE a.url = b.url
+    AND (a.created_at, a.id) > (b.created_at, b.id);
+ALTER TABLE posts
+ADD CONSTRAINT posts_url_key UNIQUE (url);
+ALTER TABLE posts DROP COLUMN canonical_url;
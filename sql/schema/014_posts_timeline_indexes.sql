@@ -0,0 +1,7 @@
+-- +goose Up
+-- The timeline filters on the followed feeds and walks them in order of either date, with the id breaking ties
+CREATE INDEX posts_feed_id_published_at_id_idx ON posts (feed_id, published_at, id);
+CREATE INDEX posts_feed_id_created_at_id_idx ON posts (feed_id, created_at, id);
+-- +goose Down
+DROP INDEX posts_feed_id_created_at_id_idx;
+DROP INDEX posts_feed_id_published_at_id_idx;
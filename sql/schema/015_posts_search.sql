@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN content TEXT;
+-- Tags are replaced by spaces so markup doesn't end up in the index
+ALTER TABLE posts
+ADD COLUMN search tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', title), 'A') || setweight(
+            to_tsvector(
+                'english',
+                regexp_replace(coalesce(description, ''), '<[^>]*>', ' ', 'g')
+            ),
+            'B'
+        ) || setweight(
+            to_tsvector(
+                'english',
+                regexp_replace(coalesce(content, ''), '<[^>]*>', ' ', 'g')
+            ),
+            'C'
+        )
+    ) STORED;
+CREATE INDEX posts_search_idx ON posts USING GIN (search);
+-- +goose Down
+DROP INDEX posts_search_idx;
+ALTER TABLE posts DROP COLUMN search;
+ALTER TABLE posts DROP COLUMN content;
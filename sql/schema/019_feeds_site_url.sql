@@ -0,0 +1,6 @@
+-- +goose Up
+-- The website behind the feed, from the channel's <link> or an imported htmlUrl
+ALTER TABLE feeds
+ADD COLUMN site_url TEXT;
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN site_url;
@@ -0,0 +1,111 @@
+-- +goose Up
+-- Normalize URLs the way normalizeFeedURL does: lowercase scheme and host, no default port, no fragment
+WITH parts AS (
+    SELECT id,
+        lower(substring(btrim(url) FROM '^([A-Za-z]+)://')) AS scheme,
+        lower(substring(btrim(url) FROM '^[A-Za-z]+://([^/?#]+)')) AS host,
+        coalesce(
+            substring(btrim(url) FROM '^[A-Za-z]+://[^/?#]+([^#]*)'),
+            ''
+        ) AS rest
+    FROM feeds
+    WHERE btrim(url) ~ '^[A-Za-z]+://[^/?#]+'
+)
+UPDATE feeds
+SET url = parts.scheme || '://' || CASE
+        WHEN (
+            parts.scheme = 'http'
+            AND parts.host LIKE '%:80'
+        )
+        OR (
+            parts.scheme = 'https'
+            AND parts.host LIKE '%:443'
+        ) THEN regexp_replace(parts.host, ':[0-9]+$', '')
+        ELSE parts.host
+    END || CASE
+        WHEN parts.rest = ''
+        OR parts.rest LIKE '?%' THEN '/' || parts.rest
+        ELSE parts.rest
+    END
+FROM parts
+WHERE feeds.id = parts.id;
+-- Feeds sharing a URL are merged into the oldest one
+CREATE TEMPORARY TABLE feed_merges ON COMMIT DROP AS
+SELECT id AS old_id,
+    first_value(id) OVER (
+        PARTITION BY url
+        ORDER BY created_at,
+            id
+    ) AS new_id
+FROM feeds;
+DELETE FROM feed_merges
+WHERE old_id = new_id;
+-- A user following several copies keeps one follow, preferably the one of the surviving feed
+DELETE FROM feed_follows
+WHERE id IN (
+        SELECT f.id
+        FROM feed_follows f
+            JOIN feed_merges m ON m.old_id = f.feed_id
+        WHERE EXISTS (
+                SELECT 1
+                FROM feed_follows k
+                    LEFT JOIN feed_merges km ON km.old_id = k.feed_id
+                WHERE k.user_id = f.user_id
+                    AND COALESCE(km.new_id, k.feed_id) = m.new_id
+                    AND (
+                        k.feed_id = m.new_id
+                        OR (k.created_at, k.id) < (f.created_at, f.id)
+                    )
+            )
+    );
+UPDATE feed_follows
+SET feed_id = feed_merges.new_id
+FROM feed_merges
+WHERE feed_follows.feed_id = feed_merges.old_id;
+-- Posts both copies stored are merged too, keeping their stars and read marks
+CREATE TEMPORARY TABLE post_merges ON COMMIT DROP AS
+SELECT p.id AS old_id,
+    (
+        SELECT k.id
+        FROM posts k
+            LEFT JOIN feed_merges km ON km.old_id = k.feed_id
+        WHERE k.canonical_url = p.canonical_url
+            AND COALESCE(km.new_id, k.feed_id) = m.new_id
+        ORDER BY (k.feed_id = m.new_id) DESC,
+            k.created_at,
+            k.id
+        LIMIT 1
+    ) AS new_id
+FROM posts p
+    JOIN feed_merges m ON m.old_id = p.feed_id;
+DELETE FROM post_merges
+WHERE old_id = new_id;
+INSERT INTO post_stars (user_id, post_id, created_at)
+SELECT post_stars.user_id,
+    post_merges.new_id,
+    post_stars.created_at
+FROM post_stars
+    JOIN post_merges ON post_merges.old_id = post_stars.post_id ON CONFLICT DO NOTHING;
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT post_reads.user_id,
+    post_merges.new_id,
+    post_reads.read_at
+FROM post_reads
+    JOIN post_merges ON post_merges.old_id = post_reads.post_id ON CONFLICT DO NOTHING;
+DELETE FROM posts USING post_merges
+WHERE posts.id = post_merges.old_id;
+UPDATE posts
+SET feed_id = feed_merges.new_id
+FROM feed_merges
+WHERE posts.feed_id = feed_merges.old_id;
+UPDATE feed_fetches
+SET feed_id = feed_merges.new_id
+FROM feed_merges
+WHERE feed_fetches.feed_id = feed_merges.old_id;
+-- Retention overrides and queued jobs of the merged copies go with them
+DELETE FROM feeds USING feed_merges
+WHERE feeds.id = feed_merges.old_id;
+ALTER TABLE feeds
+ADD CONSTRAINT feeds_url_key UNIQUE (url);
+-- +goose Down
+ALTER TABLE feeds DROP CONSTRAINT feeds_url_key;
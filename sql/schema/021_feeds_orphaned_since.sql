@@ -0,0 +1,13 @@
+-- +goose Up
+-- Set while nobody follows the feed; the pruning job deletes feeds that stay orphaned too long
+ALTER TABLE feeds
+ADD COLUMN orphaned_since TIMESTAMP;
+UPDATE feeds
+SET orphaned_since = NOW()
+WHERE NOT EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE feed_follows.feed_id = feeds.id
+    );
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN orphaned_since;
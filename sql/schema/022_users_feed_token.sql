@@ -0,0 +1,6 @@
+-- +goose Up
+-- Secret for the user's outbound feed URLs; NULL means the outbound feeds are switched off
+ALTER TABLE users
+ADD COLUMN feed_token VARCHAR(64) UNIQUE;
+-- +goose Down
+ALTER TABLE users DROP COLUMN feed_token;
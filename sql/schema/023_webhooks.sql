@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE webhooks (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    -- Key for the HMAC signature on every delivery
+    secret VARCHAR(64) NOT NULL,
+    -- Empty filters match every post from the user's follows
+    feed_ids UUID [] NOT NULL DEFAULT '{}',
+    keywords TEXT [] NOT NULL DEFAULT '{}'
+);
+CREATE INDEX webhooks_user_id_idx ON webhooks (user_id);
+-- One row per post and webhook, retried like scrape_jobs until it succeeds or runs out of attempts
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (
+        status IN ('pending', 'running', 'succeeded', 'failed')
+    ),
+    attempts INT NOT NULL DEFAULT 0,
+    run_at TIMESTAMP NOT NULL,
+    lease_expires_at TIMESTAMP,
+    last_status_code INT,
+    last_error TEXT,
+    delivered_at TIMESTAMP,
+    UNIQUE (webhook_id, post_id)
+);
+CREATE INDEX webhook_deliveries_status_run_at_idx ON webhook_deliveries (status, run_at);
+CREATE INDEX webhook_deliveries_webhook_id_created_at_idx ON webhook_deliveries (webhook_id, created_at);
+-- +goose Down
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Increases with every insert, in commit order since inserts hold a lock until they commit;
+-- the post stream uses it as the SSE event ID to resume from
+ALTER TABLE posts
+ADD COLUMN seq BIGSERIAL;
+CREATE UNIQUE INDEX posts_seq_idx ON posts (seq);
+-- +goose Down
+ALTER TABLE posts DROP COLUMN seq;
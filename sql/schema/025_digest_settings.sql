@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE digest_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    email TEXT NOT NULL,
+    frequency TEXT NOT NULL CHECK (frequency IN ('daily', 'weekly')),
+    -- Local time the digest goes out, in minutes after midnight in timezone
+    minute_of_day INT NOT NULL CHECK (
+        minute_of_day BETWEEN 0 AND 1439
+    ),
+    -- Day of a weekly digest, 0 is Sunday
+    weekday INT CHECK (
+        weekday BETWEEN 0 AND 6
+    ),
+    timezone TEXT NOT NULL,
+    -- Empty means every followed feed
+    folder_ids UUID [] NOT NULL DEFAULT '{}',
+    next_send_at TIMESTAMP NOT NULL,
+    last_sent_at TIMESTAMP,
+    -- Nothing is sent until the address is confirmed through the link mailed to it
+    email_confirmed_at TIMESTAMP,
+    confirm_token VARCHAR(64) UNIQUE
+);
+CREATE INDEX digest_settings_next_send_at_idx ON digest_settings (next_send_at);
+-- +goose Down
+DROP TABLE digest_settings;
@@ -0,0 +1,69 @@
+-- +goose Up
+CREATE TABLE filter_rules (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- NULL applies the rule to every feed the user follows
+    feed_id UUID REFERENCES feeds(id) ON DELETE CASCADE,
+    field TEXT NOT NULL CHECK (
+        field IN ('title', 'description', 'author', 'category')
+    ),
+    match_type TEXT NOT NULL CHECK (match_type IN ('keyword', 'regex')),
+    -- Long regexes are slow to run on every post, the handler caps them at the same length
+    pattern TEXT NOT NULL CHECK (char_length(pattern) <= 200)
+);
+CREATE INDEX filter_rules_user_id_idx ON filter_rules (user_id);
+-- Keywords match case-insensitively anywhere in the field, regexes are case-insensitive POSIX (~*)
+-- +goose StatementBegin
+CREATE FUNCTION filter_rule_matches(
+    field TEXT,
+    match_type TEXT,
+    pattern TEXT,
+    title TEXT,
+    description TEXT,
+    author TEXT,
+    categories TEXT []
+) RETURNS BOOLEAN LANGUAGE sql STABLE AS $$
+SELECT EXISTS (
+        SELECT 1
+        FROM unnest(
+                CASE
+                    WHEN field = 'title' THEN ARRAY [title]
+                    WHEN field = 'description' THEN ARRAY [description]
+                    WHEN field = 'author' THEN ARRAY [author]
+                    ELSE categories
+                END
+            ) AS value
+        WHERE CASE
+                WHEN match_type = 'regex' THEN value ~* pattern
+                ELSE strpos(lower(value), lower(pattern)) > 0
+            END
+    ) $$;
+-- +goose StatementEnd
+-- Whether any of the viewer's rules hides post, the one place the timeline and unread counts ask
+-- +goose StatementBegin
+CREATE FUNCTION post_hidden_for_user(viewer_id UUID, post posts) RETURNS BOOLEAN LANGUAGE sql STABLE AS $$
+SELECT EXISTS (
+        SELECT 1
+        FROM filter_rules
+        WHERE filter_rules.user_id = viewer_id
+            AND (
+                filter_rules.feed_id IS NULL
+                OR filter_rules.feed_id = post.feed_id
+            )
+            AND filter_rule_matches(
+                filter_rules.field,
+                filter_rules.match_type,
+                filter_rules.pattern,
+                post.title,
+                post.description,
+                post.author,
+                post.categories
+            )
+    ) $$;
+-- +goose StatementEnd
+-- +goose Down
+DROP FUNCTION post_hidden_for_user;
+DROP FUNCTION filter_rule_matches;
+DROP TABLE filter_rules;
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// newPostsChannel is the Postgres NOTIFY channel the scraper announces new posts on.
+const newPostsChannel = "new_posts"
+
+// createPostsInSeqOrder stores posts through qtx, which must be inside a transaction.
+// Seqs come from a sequence, which hands them out at insert time, while fetches commit in any order.
+// Holding a lock from the insert until the transaction ends makes seqs visible in order, so a stream
+// resuming after seq N can never miss a post that commits later with a lower one.
+// The lock is held until commit, so callers should make their other writes before calling this.
+func createPostsInSeqOrder(ctx context.Context, qtx *database.Queries, params database.CreatePostsParams) ([]database.Post, error) {
+	// Most fetches find nothing new and have no reason to queue up behind the others
+	if len(params.Ids) == 0 {
+		return nil, nil
+	}
+	err := qtx.LockPostSeq(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return qtx.CreatePosts(ctx, params)
+}
+
+// newPostsNotification is the NOTIFY payload for one batch of posts stored by a fetch.
+// Payloads are limited to 8000 bytes, so it names the seq range instead of listing the posts.
+type newPostsNotification struct {
+	FeedID uuid.UUID `json:"feed_id"`
+	MinSeq int64     `json:"min_seq"`
+	MaxSeq int64     `json:"max_seq"`
+}
+
+// newPostsPayload builds the NOTIFY payload for posts that were just stored for a feed.
+func newPostsPayload(feedID uuid.UUID, posts []database.Post) (string, error) {
+	n := newPostsNotification{FeedID: feedID, MinSeq: posts[0].Seq, MaxSeq: posts[0].Seq}
+	for _, post := range posts {
+		n.MinSeq = min(n.MinSeq, post.Seq)
+		n.MaxSeq = max(n.MaxSeq, post.Seq)
+	}
+	payload, err := json.Marshal(n)
+	return string(payload), err
+}
+
+// postSubscriber is one open stream, waiting for posts from the feeds its user follows.
+type postSubscriber struct {
+	UserID uuid.UUID
+	Posts  chan database.Post // New posts, in seq order
+	// Signalled when posts may have been missed (a full buffer or a lost database connection),
+	// the subscriber then catches up from the database
+	Resync chan struct{}
+}
+
+// postBroadcaster fans new posts out to the open streams of this instance.
+type postBroadcaster struct {
+	mu          sync.Mutex
+	subscribers map[*postSubscriber]bool
+	done        chan struct{} // Closed on shutdown so open streams end instead of holding the server up
+	closeOnce   sync.Once
+}
+
+func newPostBroadcaster() *postBroadcaster {
+	return &postBroadcaster{
+		subscribers: map[*postSubscriber]bool{},
+		done:        make(chan struct{}),
+	}
+}
+
+// shutdown ends every open stream; clients reconnect elsewhere with Last-Event-ID.
+func (b *postBroadcaster) shutdown() {
+	b.closeOnce.Do(func() { close(b.done) })
+}
+
+// subscribe registers a stream for the user's posts. Call unsubscribe when it closes.
+func (b *postBroadcaster) subscribe(userID uuid.UUID) *postSubscriber {
+	sub := &postSubscriber{
+		UserID: userID,
+		Posts:  make(chan database.Post, 64),
+		Resync: make(chan struct{}, 1),
+	}
+	b.mu.Lock()
+	b.subscribers[sub] = true
+	b.mu.Unlock()
+	return sub
+}
+
+func (b *postBroadcaster) unsubscribe(sub *postSubscriber) {
+	b.mu.Lock()
+	delete(b.subscribers, sub)
+	b.mu.Unlock()
+}
+
+// publish hands posts to the subscribers among followers. It never blocks:
+// a subscriber that can't keep up is told to resync instead.
+func (b *postBroadcaster) publish(followers []uuid.UUID, posts []database.Post) {
+	following := map[uuid.UUID]bool{}
+	for _, userID := range followers {
+		following[userID] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subscribers {
+		if !following[sub.UserID] {
+			continue
+		}
+		for _, post := range posts {
+			select {
+			case sub.Posts <- post:
+			default:
+				sub.resync()
+			}
+		}
+	}
+}
+
+// resyncAll tells every subscriber to catch up from the database.
+func (b *postBroadcaster) resyncAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subscribers {
+		sub.resync()
+	}
+}
+
+func (sub *postSubscriber) resync() {
+	select {
+	case sub.Resync <- struct{}{}:
+	default: // Already pending
+	}
+}
+
+// listenForPosts feeds the broadcaster from NOTIFYs on newPostsChannel until ctx is cancelled,
+// so posts stored by any instance reach the streams open on this one.
+func (b *postBroadcaster) listenForPosts(ctx context.Context, dbURL string, db *database.Queries) {
+	listener := pq.NewListener(dbURL, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Println("Post listener:", err)
+		}
+	})
+	defer listener.Close()
+
+	err := listener.Listen(newPostsChannel)
+	if err != nil {
+		log.Println("Error listening for new posts:", err)
+		return
+	}
+
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			// Notices a dead connection that would otherwise sit silent
+			go listener.Ping()
+		case notification := <-listener.Notify:
+			// nil means the connection was re-established, and anything sent meanwhile is lost
+			if notification == nil {
+				b.resyncAll()
+				continue
+			}
+			b.handleNotification(ctx, db, notification.Extra)
+		}
+	}
+}
+
+// handleNotification loads the posts a NOTIFY announced and publishes them to their feed's followers.
+func (b *postBroadcaster) handleNotification(ctx context.Context, db *database.Queries, payload string) {
+	n := newPostsNotification{}
+	err := json.Unmarshal([]byte(payload), &n)
+	if err != nil {
+		log.Println("Error decoding new posts notification:", err)
+		return
+	}
+
+	posts, err := db.GetPostsForFeedInSeqRange(ctx, database.GetPostsForFeedInSeqRangeParams{
+		FeedID: n.FeedID,
+		MinSeq: n.MinSeq,
+		MaxSeq: n.MaxSeq,
+	})
+	if err != nil {
+		log.Println("Error getting announced posts:", err)
+		b.resyncAll()
+		return
+	}
+	followers, err := db.GetFeedFollowerIDs(ctx, n.FeedID)
+	if err != nil {
+		log.Println("Error getting feed followers:", err)
+		b.resyncAll()
+		return
+	}
+
+	b.publish(followers, posts)
+}
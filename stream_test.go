@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestPostBroadcasterPublishesToFollowersOnly(t *testing.T) {
+	b := newPostBroadcaster()
+	follower, other := uuid.New(), uuid.New()
+	followerSub := b.subscribe(follower)
+	otherSub := b.subscribe(other)
+	defer b.unsubscribe(followerSub)
+	defer b.unsubscribe(otherSub)
+
+	b.publish([]uuid.UUID{follower}, []database.Post{{Seq: 1}, {Seq: 2}})
+
+	if len(followerSub.Posts) != 2 {
+		t.Fatalf("follower got %d posts, want 2", len(followerSub.Posts))
+	}
+	if post := <-followerSub.Posts; post.Seq != 1 {
+		t.Errorf("first post has seq %d, want 1", post.Seq)
+	}
+	if len(otherSub.Posts) != 0 {
+		t.Errorf("non-follower got %d posts", len(otherSub.Posts))
+	}
+}
+
+func TestPostBroadcasterResyncsSlowSubscribers(t *testing.T) {
+	b := newPostBroadcaster()
+	userID := uuid.New()
+	sub := b.subscribe(userID)
+	defer b.unsubscribe(sub)
+
+	posts := make([]database.Post, cap(sub.Posts)+1)
+	b.publish([]uuid.UUID{userID}, posts)
+
+	select {
+	case <-sub.Resync:
+	default:
+		t.Fatal("a full subscriber was not told to resync")
+	}
+
+	// Publishing after unsubscribing must not block or reach the stream
+	b.unsubscribe(sub)
+	for len(sub.Posts) > 0 {
+		<-sub.Posts
+	}
+	b.publish([]uuid.UUID{userID}, posts[:1])
+	if len(sub.Posts) != 0 {
+		t.Error("unsubscribed stream still gets posts")
+	}
+}
+
+func TestNewPostsPayload(t *testing.T) {
+	feedID := uuid.New()
+	payload, err := newPostsPayload(feedID, []database.Post{{Seq: 7}, {Seq: 5}, {Seq: 9}})
+	if err != nil {
+		t.Fatalf("newPostsPayload() error = %v", err)
+	}
+
+	n := newPostsNotification{}
+	err = json.Unmarshal([]byte(payload), &n)
+	if err != nil {
+		t.Fatalf("payload %q does not decode: %v", payload, err)
+	}
+	if n.FeedID != feedID || n.MinSeq != 5 || n.MaxSeq != 9 {
+		t.Errorf("got %+v, want seqs 5 to 9 of feed %s", n, feedID)
+	}
+}
+
+// TestPostSeqsFollowCommitOrder stores posts from two transactions that overlap, the second starting
+// while the first is still open. The second must not become visible first, or a stream that saw its
+// seq would resume past the first one's posts. Needs TEST_DATABASE_URL pointing at a migrated database.
+func TestPostSeqsFollowCommitOrder(t *testing.T) {
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	conn, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ctx := context.Background()
+	db := database.New(conn)
+
+	feed, err := db.CreateFeed(ctx, database.CreateFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Name:      "Seq test",
+		Url:       "https://example.com/" + uuid.NewString() + ".xml",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Exec("DELETE FROM feeds WHERE id = $1", feed.ID) })
+
+	store := func(tx *sql.Tx) (database.Post, error) {
+		url := "https://example.com/" + uuid.NewString()
+		posts, err := createPostsInSeqOrder(ctx, db.WithTx(tx), database.CreatePostsParams{
+			Now:           time.Now().UTC(),
+			Name:          feed.Name,
+			FeedID:        feed.ID,
+			Ids:           []uuid.UUID{uuid.New()},
+			Titles:        []string{"Post"},
+			Descriptions:  []string{""},
+			PublishedAts:  []time.Time{time.Now().UTC()},
+			Urls:          []string{url},
+			CanonicalUrls: []string{url},
+			Contents:      []string{""},
+			Authors:       []string{""},
+			Categories:    []string{""},
+		})
+		if err != nil {
+			return database.Post{}, err
+		}
+		return posts[0], nil
+	}
+
+	first, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Rollback()
+	firstPost, err := store(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type stored struct {
+		post database.Post
+		err  error
+	}
+	secondDone := make(chan stored, 1)
+	go func() {
+		second, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			secondDone <- stored{err: err}
+			return
+		}
+		defer second.Rollback()
+		post, err := store(second)
+		if err == nil {
+			err = second.Commit()
+		}
+		secondDone <- stored{post, err}
+	}()
+
+	select {
+	case <-secondDone:
+		t.Fatal("the second transaction committed while the first one was still open")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	err = first.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := <-secondDone
+	if second.err != nil {
+		t.Fatal(second.err)
+	}
+	if second.post.Seq <= firstPost.Seq {
+		t.Errorf("seq %d committed after seq %d", second.post.Seq, firstPost.Seq)
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/PuneethM06/rssagg/internal/database"
+	"github.com/google/uuid"
+)
+
+// webhookTimeout bounds a single delivery attempt, including reading the response.
+const webhookTimeout = 10 * time.Second
+
+// webhookClient is shared by all deliveries so connections to the same endpoint are reused.
+// It only connects to public addresses, whatever the webhook's host resolves to by the time it is sent.
+var webhookClient = newWebhookClient()
+
+func newWebhookClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // A proxy would make the dial check below look at the proxy instead of the receiver
+	transport.DialContext = (&net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !webhookTargetAllowed(addrPort.Addr()) {
+				return errPrivateWebhookTarget
+			}
+			return nil
+		},
+	}).DialContext
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+	}
+}
+
+var errPrivateWebhookTarget = errors.New("webhooks can only be sent to public addresses")
+
+// nonPublicPrefixes are ranges that aren't reachable on the internet, on top of what netip already knows about.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"), // Benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64, which may lead anywhere behind the gateway
+}
+
+// webhookTargetAllowed reports whether a webhook may be sent to addr. Loopback, private, link-local
+// (cloud metadata endpoints live there) and other non-public addresses are refused, so webhooks
+// can't be used to probe the network the server runs in.
+func webhookTargetAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkWebhookHost resolves host and fails unless every address it has is one webhooks may be sent to.
+func checkWebhookHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !webhookTargetAllowed(addr) {
+			return errPrivateWebhookTarget
+		}
+	}
+	return nil
+}
+
+// webhookPayload is the JSON body POSTed to a webhook for every new post.
+type webhookPayload struct {
+	Event      string    `json:"event"`
+	DeliveryID uuid.UUID `json:"delivery_id"` // Stays the same across retries, so receivers can drop duplicates
+	WebhookID  uuid.UUID `json:"webhook_id"`
+	Post       Post      `json:"post"`
+	Feed       Feed      `json:"feed"`
+}
+
+// webhookDeliverer sends queued webhook deliveries, retrying failed ones with backoff.
+// Deliveries are leased in the database like scrape jobs, so several instances can share the work.
+type webhookDeliverer struct {
+	DB            *database.Queries
+	Concurrency   int           // Deliveries sent at the same time
+	PollInterval  time.Duration // How long to wait when nothing is due
+	LeaseDuration time.Duration // How long a claimed delivery stays reserved for this instance
+	MaxAttempts   int           // Failed attempts before a delivery is given up on
+}
+
+// startDelivering runs the dispatcher and its workers until ctx is cancelled.
+// Once cancelled no new deliveries are handed out, and it returns after the ones in flight have been sent.
+func (d *webhookDeliverer) startDelivering(ctx context.Context) {
+	deliveries := make(chan database.WebhookDelivery)
+
+	// Each worker takes the next delivery as soon as it is free, so one slow receiver only ties up its own worker
+	wg := &sync.WaitGroup{}
+	for i := 0; i < d.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for delivery := range deliveries {
+				// In-flight deliveries are allowed to finish on shutdown
+				deliveryCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*webhookTimeout)
+				d.deliver(deliveryCtx, delivery)
+				cancel()
+			}
+		}()
+	}
+
+	d.dispatch(ctx, deliveries)
+	close(deliveries)
+	wg.Wait()
+}
+
+// dispatch claims due deliveries and hands them to the workers until ctx is cancelled.
+func (d *webhookDeliverer) dispatch(ctx context.Context, deliveries chan<- database.WebhookDelivery) {
+	for {
+		now := time.Now().UTC()
+		claimed, err := d.DB.ClaimWebhookDeliveries(ctx, database.ClaimWebhookDeliveriesParams{
+			LeaseUntil: now.Add(d.LeaseDuration),
+			Now:        now,
+			BatchSize:  int32(d.Concurrency),
+		})
+		if err != nil && ctx.Err() == nil {
+			log.Println("Error claiming webhook deliveries:", err)
+		}
+
+		for _, delivery := range claimed {
+			select {
+			case deliveries <- delivery:
+			case <-ctx.Done():
+				// Unsent deliveries keep their lease and are picked up again once it runs out
+				return
+			}
+		}
+
+		// Nothing was due, so wait before asking the database again
+		if len(claimed) == 0 {
+			select {
+			case <-time.After(d.PollInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// deliver sends one delivery and records the outcome: done, retried later or failed for good.
+func (d *webhookDeliverer) deliver(ctx context.Context, delivery database.WebhookDelivery) {
+	// The delivery is deleted along with its webhook or post, so a missing one means there is nothing left to do
+	webhook, err := d.DB.GetWebhookByID(ctx, delivery.WebhookID)
+	if err != nil {
+		log.Println("Error getting webhook for delivery:", err)
+		return
+	}
+	post, err := d.DB.GetPostByID(ctx, delivery.PostID)
+	if err != nil {
+		log.Println("Error getting post for webhook delivery:", err)
+		return
+	}
+	feed, err := d.DB.GetFeedByID(ctx, post.FeedID)
+	if err != nil {
+		log.Println("Error getting feed for webhook delivery:", err)
+		return
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		Event:      "post.created",
+		DeliveryID: delivery.ID,
+		WebhookID:  webhook.ID,
+		Post:       databasePosttoPost(post),
+		Feed:       databaseFeedToFeed(feed),
+	})
+	if err != nil {
+		log.Println("Error encoding webhook payload:", err)
+		return
+	}
+
+	meta, err := sendWebhook(ctx, webhook.Url, webhook.Secret, delivery.ID, body, time.Now().UTC())
+	statusCode := sql.NullInt32{Int32: int32(meta.StatusCode), Valid: meta.StatusCode != 0}
+	if err == nil {
+		err = d.DB.CompleteWebhookDelivery(ctx, database.CompleteWebhookDeliveryParams{
+			ID:             delivery.ID,
+			LastStatusCode: statusCode,
+			DeliveredAt:    sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		})
+		if err != nil {
+			log.Println("Error completing webhook delivery:", err)
+		}
+		return
+	}
+
+	lastError := sql.NullString{String: err.Error(), Valid: true}
+	if int(delivery.Attempts) >= d.MaxAttempts {
+		log.Printf("Giving up on webhook delivery %s to %s after %d attempts: %v", delivery.ID, webhook.Url, delivery.Attempts, err)
+		err = d.DB.FailWebhookDelivery(ctx, database.FailWebhookDeliveryParams{
+			ID:             delivery.ID,
+			LastStatusCode: statusCode,
+			LastError:      lastError,
+		})
+		if err != nil {
+			log.Println("Error failing webhook delivery:", err)
+		}
+		return
+	}
+
+	// Same backoff as failed fetches, including a receiver's Retry-After, but never more than
+	// retryMaxDelay so a receiver can't park its deliveries for good
+	now := time.Now().UTC()
+	runAt := retryAt(now, int(delivery.Attempts), meta)
+	if latest := now.Add(retryMaxDelay); runAt.After(latest) {
+		runAt = latest
+	}
+	err = d.DB.RetryWebhookDeliveryLater(ctx, database.RetryWebhookDeliveryLaterParams{
+		ID:             delivery.ID,
+		RunAt:          runAt,
+		LastStatusCode: statusCode,
+		LastError:      lastError,
+	})
+	if err != nil {
+		log.Println("Error rescheduling webhook delivery:", err)
+	}
+}
+
+// sendWebhook POSTs a signed payload to url. Any 2xx response counts as delivered.
+func sendWebhook(ctx context.Context, url, secret string, deliveryID uuid.UUID, body []byte, now time.Time) (fetchMeta, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fetchMeta{}, err
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Rssagg-Event", "post.created")
+	req.Header.Set("X-Rssagg-Delivery", deliveryID.String())
+	req.Header.Set("X-Rssagg-Timestamp", timestamp)
+	req.Header.Set("X-Rssagg-Signature", signWebhookPayload(secret, timestamp, body))
+
+	start := time.Now()
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return fetchMeta{Duration: time.Since(start)}, err
+	}
+	defer resp.Body.Close()
+
+	// Read a little of the body so the connection can be reused, receivers have nothing to tell us
+	n, _ := io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	meta := fetchMeta{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Bytes:      n,
+		Duration:   time.Since(start),
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return meta, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return meta, nil
+}
+
+// signWebhookPayload returns the X-Rssagg-Signature header for a payload: "sha256=" and the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook's secret. Covering the timestamp
+// lets receivers reject replays of old deliveries.
+func signWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
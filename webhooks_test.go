@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// allowLoopbackWebhooks lets sendWebhook reach httptest servers, which listen on loopback.
+func allowLoopbackWebhooks(t *testing.T) {
+	client := webhookClient
+	webhookClient = &http.Client{Timeout: webhookTimeout}
+	t.Cleanup(func() { webhookClient = client })
+}
+
+func TestSendWebhookSignsPayload(t *testing.T) {
+	allowLoopbackWebhooks(t)
+	secret := "s3cret"
+	deliveryID := uuid.New()
+	now := time.Unix(1700000000, 0).UTC()
+	body := []byte(`{"event":"post.created"}`)
+
+	var got *http.Request
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	meta, err := sendWebhook(context.Background(), srv.URL, secret, deliveryID, body, now)
+	if err != nil {
+		t.Fatalf("sendWebhook() error = %v", err)
+	}
+	if meta.StatusCode != http.StatusNoContent {
+		t.Errorf("status code = %d, want %d", meta.StatusCode, http.StatusNoContent)
+	}
+
+	if got.Method != http.MethodPost || string(gotBody) != string(body) {
+		t.Errorf("got %s with body %q", got.Method, gotBody)
+	}
+	if got.Header.Get("X-Rssagg-Delivery") != deliveryID.String() {
+		t.Errorf("X-Rssagg-Delivery = %q", got.Header.Get("X-Rssagg-Delivery"))
+	}
+	timestamp := got.Header.Get("X-Rssagg-Timestamp")
+	if timestamp != strconv.FormatInt(now.Unix(), 10) {
+		t.Errorf("X-Rssagg-Timestamp = %q", timestamp)
+	}
+
+	// Verify the way a receiver would
+	want := signWebhookPayload(secret, timestamp, gotBody)
+	if !hmac.Equal([]byte(got.Header.Get("X-Rssagg-Signature")), []byte(want)) {
+		t.Errorf("X-Rssagg-Signature = %q, want %q", got.Header.Get("X-Rssagg-Signature"), want)
+	}
+	if signWebhookPayload("other", timestamp, gotBody) == want {
+		t.Error("signature does not depend on the secret")
+	}
+	if signWebhookPayload(secret, "1700000001", gotBody) == want {
+		t.Error("signature does not depend on the timestamp")
+	}
+}
+
+func TestSendWebhookFailsOnErrorStatus(t *testing.T) {
+	allowLoopbackWebhooks(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	meta, err := sendWebhook(context.Background(), srv.URL, "s3cret", uuid.New(), []byte(`{}`), time.Now())
+	if err == nil {
+		t.Fatal("sendWebhook() succeeded on a 503")
+	}
+	if meta.StatusCode != http.StatusServiceUnavailable || meta.Header.Get("Retry-After") != "120" {
+		t.Errorf("meta = %+v, want the 503 and its Retry-After", meta)
+	}
+}
+
+func TestSendWebhookRefusesLoopback(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	_, err := sendWebhook(context.Background(), srv.URL, "s3cret", uuid.New(), []byte(`{}`), time.Now())
+	if !errors.Is(err, errPrivateWebhookTarget) || called {
+		t.Errorf("sendWebhook() to loopback error = %v, called = %v, want errPrivateWebhookTarget", err, called)
+	}
+}
+
+func TestWebhookTargetAllowed(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := webhookTargetAllowed(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("webhookTargetAllowed(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}